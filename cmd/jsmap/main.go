@@ -8,14 +8,20 @@ import (
 	"os"
 	"strings"
 
+	"github.com/0xhkx0/jsmap/internal/pool"
 	"github.com/0xhkx0/jsmap/pkg/analyzer"
 	"github.com/0xhkx0/jsmap/pkg/client"
 	"github.com/0xhkx0/jsmap/pkg/crawler"
 	"github.com/0xhkx0/jsmap/pkg/output"
+	"github.com/0xhkx0/jsmap/pkg/sourcemap"
 	"github.com/0xhkx0/jsmap/pkg/types"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		os.Exit(runRulesCommand(os.Args[2:]))
+	}
+
 	// Define CLI flags
 	urlInput := flag.String("u", "", "Target URL to analyze")
 	requestFile := flag.String("r", "", "HTTP request file (raw format or Burp XML/JSON)")
@@ -23,14 +29,27 @@ func main() {
 	urlList := flag.String("ul", "", "File containing list of URLs (one per line)")
 	crawlFlag := flag.Bool("crawl", false, "Auto-crawl URL to find and analyze all JS files")
 	outputFile := flag.String("o", "", "Output file (JSON, CSV, or HTML)")
-	format := flag.String("format", "table", "Output format: table, json, csv, html")
+	format := flag.String("format", "table", "Output format: table, json, csv, html, markdown, jsonl, sarif, openapi, postman, burp")
+	templatePath := flag.String("template", "", "Render output through a Go text/template (or html/template for .html files)")
+	redact := flag.String("redact", string(output.RedactPartial), "Secret redaction policy: partial, full, hash, none")
+	revealSecrets := flag.Bool("reveal-secrets", false, "Show raw secret values in output (same as -redact none)")
 	cookie := flag.String("cookie", "", "HTTP Cookie header value")
+	var headers headerFlags
+	flag.Var(&headers, "H", "Custom request header (\"Name: value\"), can be repeated")
 	userAgent := flag.String("ua", "jsmap/1.0", "User-Agent header")
 	timeout := flag.Int("timeout", 30, "Request timeout in seconds")
 	proxy := flag.String("proxy", "", "HTTP proxy URL (e.g., http://127.0.0.1:8080)")
 	quiet := flag.Bool("q", false, "Quiet mode")
 	verbose := flag.Bool("v", false, "Verbose output")
 	threaded := flag.Int("t", 1, "Number of concurrent requests")
+	rateLimit := flag.Float64("rate", 0, "Maximum requests per second (0 for no limit)")
+	rulesPath := flag.String("rules", "", "Custom rule file or directory (YAML/JSON)")
+	dumpSources := flag.String("dump-sources", "", "Write original sources recovered from source maps under this directory")
+	fetchSources := flag.Bool("fetch-sources", false, "Fetch original sources that source maps list without sourcesContent")
+	defaultThresholds := analyzer.DefaultEntropyThresholds()
+	entropyHex := flag.Float64("entropy-hex", defaultThresholds.Hex, "Minimum entropy for generic hex secrets")
+	entropyBase64 := flag.Float64("entropy-base64", defaultThresholds.Base64, "Minimum entropy for generic base64 secrets")
+	entropyAlnum := flag.Float64("entropy-alnum", defaultThresholds.Alnum, "Minimum entropy for generic alphanumeric secrets")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `jsmap - JavaScript Bug Bounty Scanner
@@ -43,6 +62,7 @@ Usage:
   jsmap -r <request_file> [options] # Analyze HTTP request file
   jsmap -ul <url_list> [options]  # Analyze multiple URLs
   jsmap -f <js_file> [options]    # Analyze local JavaScript file
+  jsmap rules validate [-rules <file|dir>] # Check rule examples
 
 Input Options:
   -u <url>          Target URL to fetch and analyze
@@ -53,16 +73,33 @@ Input Options:
 
 Authentication Options:
   -cookie <string>  HTTP Cookie value
+  -H <header>       Custom header "Name: value" (repeatable)
   -ua <string>      User-Agent (default: jsmap/1.0)
 
 Request Options:
   -timeout <int>    Request timeout in seconds (default: 30)
   -proxy <url>      HTTP proxy URL for requests
   -t <int>          Concurrent requests (default: 1)
+  -rate <float>     Maximum requests per second, 0 for no limit (default: 0)
+
+Detection Options:
+  -rules <path>     Custom rule file or directory (YAML/JSON), merged with built-ins
+  -entropy-hex <f>  Minimum entropy for generic hex secrets (default: 3.0)
+  -entropy-base64 <f>  Minimum entropy for generic base64 secrets (default: 4.2)
+  -entropy-alnum <f>   Minimum entropy for generic alphanumeric secrets (default: 3.7)
+
+Source Map Options:
+  -dump-sources <dir>  Write original sources from source maps under <dir>/<host>/
+                       with a manifest.json of their sizes and SHA-256 hashes
+  -fetch-sources       Fetch original sources missing from sourcesContent,
+                       resolved against sourceRoot and the map URL
 
 Output Options:
   -o <file>         Output file
-  -format <fmt>     Output format: table, json, csv, html (default: table)
+  -format <fmt>     Output format: table, json, csv, html, markdown, jsonl, sarif, openapi, postman, burp (default: table)
+  -template <file>  Render output through a Go template (overrides -format)
+  -redact <policy>  Secret redaction: partial, full, hash, none (default: partial)
+  -reveal-secrets   Show raw secret values (same as -redact none)
   -q                Quiet mode (suppress output)
   -v                Verbose output
 
@@ -74,6 +111,9 @@ Examples:
   jsmap -ul targets.txt -o results.json
   jsmap -f app.js -format json -q
   jsmap -u https://api.target.com -proxy http://127.0.0.1:8080 -v
+  jsmap -u https://target.com -crawl -rules ./rules/
+  jsmap -u https://target.com -crawl -dump-sources ./sources
+  jsmap rules validate -rules ./rules/
 `)
 	}
 
@@ -106,18 +146,71 @@ Examples:
 		os.Exit(1)
 	}
 
+	redaction, err := output.ParseRedaction(*redact)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *revealSecrets {
+		redaction = output.RedactNone
+	}
+
 	// Setup HTTP client
 	httpClient := client.New(&client.Config{
 		UserAgent: *userAgent,
 		Cookie:    *cookie,
+		Headers:   headers.values,
 		Timeout:   *timeout,
 		ProxyURL:  *proxy,
 		Verbose:   *verbose,
+		RateLimit: *rateLimit,
 	})
 
+	ruleSets, err := loadRuleSets(*rulesPath, analyzer.EntropyThresholds{
+		Hex:    *entropyHex,
+		Base64: *entropyBase64,
+		Alnum:  *entropyAlnum,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading rules: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Create aggregated findings
 	allFindings := types.NewAggregatedFindings()
-	jsAnalyzer := analyzer.NewAnalyzer(*verbose)
+	jsAnalyzer := analyzer.NewAnalyzerWithRules(*verbose, ruleSets)
+
+	// Original sources of source maps found while crawling are written out
+	// as they are discovered
+	maps := sourceMapOptions{fetch: *fetchSources}
+	if *dumpSources != "" {
+		maps.dumper = sourcemap.NewDumper(*dumpSources)
+	}
+
+	// JSON Lines are streamed as each source is analyzed, so a long crawl
+	// shows results immediately and keeps them if it is interrupted. A
+	// template overrides -format, jsonl included.
+	var stream *output.JSONLWriter
+	var streamFile *os.File
+	if *format == "jsonl" && *templatePath == "" {
+		var w io.Writer = io.Discard
+		if *outputFile != "" {
+			streamFile, err = os.Create(*outputFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				os.Exit(1)
+			}
+			w = streamFile
+		} else if !*quiet {
+			// Every package prints its diagnostics to os.Stdout; send them
+			// to stderr so stdout carries nothing but JSON Lines. This runs
+			// before any work starts, so no goroutine sees the swap.
+			w = os.Stdout
+			os.Stdout = os.Stderr
+		}
+		stream = output.NewJSONLWriter(w, redaction)
+		stream.Attach(allFindings)
+	}
 
 	// Process input
 	switch {
@@ -125,7 +218,7 @@ Examples:
 		if !*quiet && *verbose {
 			fmt.Printf("[*] Crawling URL: %s\n", *urlInput)
 		}
-		if err := processCrawl(*urlInput, httpClient, jsAnalyzer, allFindings, *verbose); err != nil {
+		if err := processCrawl(*urlInput, httpClient, jsAnalyzer, allFindings, maps, *threaded, *verbose); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -152,7 +245,7 @@ Examples:
 		if !*quiet && *verbose {
 			fmt.Printf("[*] Processing request file: %s\n", *requestFile)
 		}
-		if err := processRequestFile(*requestFile, httpClient, jsAnalyzer, allFindings, *crawlFlag, *verbose); err != nil {
+		if err := processRequestFile(*requestFile, httpClient, jsAnalyzer, allFindings, maps, *crawlFlag, *threaded, *verbose); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -171,20 +264,63 @@ Examples:
 	}
 
 	if !*quiet && *verbose {
-		fmt.Printf("[+] Total findings: %d\n", len(allFindings.Endpoints)+len(allFindings.URLs)+len(allFindings.Secrets)+len(allFindings.Emails)+len(allFindings.Files))
+		fmt.Printf("[+] Total findings: %d\n", len(allFindings.Findings))
+	}
+
+	if dumper := maps.dumper; dumper != nil {
+		if err := dumper.WriteManifest(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing source manifest: %v\n", err)
+			os.Exit(1)
+		}
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "[+] Recovered %d original sources to: %s\n", dumper.Count(), *dumpSources)
+		}
+	}
+
+	if stream != nil {
+		if streamFile != nil {
+			if err := streamFile.Close(); err != nil && stream.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := stream.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			os.Exit(1)
+		}
+		if *outputFile != "" && !*quiet {
+			fmt.Printf("[+] Results saved to: %s\n", *outputFile)
+		}
+		return
 	}
 
 	// Output results
 	var outputStr string
-	switch *format {
-	case "json":
-		outputStr = output.AggregatedToJSON(allFindings)
-	case "csv":
-		outputStr = output.AggregatedToCSV(allFindings)
-	case "html":
-		outputStr = output.AggregatedToHTML(allFindings)
+	switch {
+	case *templatePath != "":
+		outputStr, err = output.AggregatedToTemplate(allFindings, redaction, *templatePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering template: %v\n", err)
+			os.Exit(1)
+		}
+	case *format == "json":
+		outputStr = output.AggregatedToJSON(allFindings, redaction)
+	case *format == "csv":
+		outputStr = output.AggregatedToCSV(allFindings, redaction)
+	case *format == "html":
+		outputStr = output.AggregatedToHTML(allFindings, redaction)
+	case *format == "markdown":
+		outputStr = output.AggregatedToMarkdown(allFindings, redaction)
+	case *format == "openapi":
+		outputStr = output.AggregatedToOpenAPI(allFindings)
+	case *format == "sarif":
+		outputStr = output.AggregatedToSARIF(allFindings, redaction)
+	case *format == "postman":
+		outputStr = output.AggregatedToPostman(allFindings, httpClient.Config, *urlInput)
+	case *format == "burp":
+		outputStr = output.AggregatedToBurp(allFindings, httpClient.Config, *urlInput)
 	default: // table
-		outputStr = output.AggregatedToTable(allFindings)
+		outputStr = output.AggregatedToTable(allFindings, redaction)
 	}
 
 	if *outputFile != "" {
@@ -220,17 +356,39 @@ func processURLList(filePath string, httpClient *client.HTTPClient, jsAnalyzer *
 		return err
 	}
 
-	for _, url := range urls {
-		if err := processURL(url, httpClient, jsAnalyzer, allFindings); err != nil {
-			fmt.Fprintf(os.Stderr, "Error processing %s: %v\n", url, err)
-		}
+	type urlResult struct {
+		findings   *types.Findings
+		statusCode int
+		err        error
 	}
+	results := make([]urlResult, len(urls))
+
+	// Results are merged in input order, so output is stable regardless of
+	// thread count, and as soon as every earlier URL is done
+	pool.RunOrdered(len(urls), threads, func(i int) {
+		content, statusCode, err := httpClient.FetchURL(urls[i])
+		if err != nil {
+			results[i] = urlResult{err: err}
+			return
+		}
+		results[i] = urlResult{
+			findings:   jsAnalyzer.Analyze(content, urls[i]),
+			statusCode: statusCode,
+		}
+	}, func(i int) {
+		if results[i].err != nil {
+			fmt.Fprintf(os.Stderr, "Error processing %s: %v\n", urls[i], results[i].err)
+			return
+		}
+		allFindings.AddFindings(results[i].findings, urls[i], urls[i], results[i].statusCode)
+		results[i] = urlResult{}
+	})
 
 	return nil
 }
 
 // processRequestFile handles raw HTTP request files
-func processRequestFile(filePath string, httpClient *client.HTTPClient, jsAnalyzer *analyzer.Analyzer, allFindings *types.AggregatedFindings, crawl bool, verbose bool) error {
+func processRequestFile(filePath string, httpClient *client.HTTPClient, jsAnalyzer *analyzer.Analyzer, allFindings *types.AggregatedFindings, maps sourceMapOptions, crawl bool, threads int, verbose bool) error {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
@@ -244,26 +402,29 @@ func processRequestFile(filePath string, httpClient *client.HTTPClient, jsAnalyz
 		return err
 	}
 
+	keepAuthContext(httpClient.Config, headers)
+
 	if method != "GET" && method != "get" {
 		fmt.Printf("[!] Note: Request uses %s method, fetching as GET\n", method)
 	}
 
 	// Create request with custom headers
-	req, _ := http.NewRequest("GET", targetURL, nil)
+	req, err := http.NewRequest("GET", targetURL, nil)
+	if err != nil {
+		return err
+	}
 	for k, v := range headers {
 		if strings.ToLower(k) != "host" && strings.ToLower(k) != "content-length" {
 			req.Header.Set(k, v)
 		}
 	}
 
-	resp, err := httpClient.Client.Do(req)
+	// Sent through the client so the request counts against the rate limit
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	htmlContent := string(body)
+	htmlContent := resp.Body
 
 	// Check if response is HTML and crawl for JS files if enabled
 	if crawl && (strings.Contains(htmlContent, "<script") || strings.Contains(htmlContent, ".js")) {
@@ -271,12 +432,21 @@ func processRequestFile(filePath string, httpClient *client.HTTPClient, jsAnalyz
 			fmt.Printf("[*] HTML response detected, crawling for JavaScript files...\n")
 		}
 
-		// Create crawler config
+		// Create crawler config; each crawl level is analyzed as soon as it
+		// is downloaded
 		crawlConfig := &crawler.Config{
-			TargetURL:  targetURL,
-			HTTPClient: httpClient.Client,
-			FetchURL:   httpClient.FetchURL,
-			Verbose:    verbose,
+			TargetURL:    targetURL,
+			Fetch:        httpClient.Fetch,
+			FetchSources: maps.fetch,
+			Threads:      threads,
+			Verbose:      verbose,
+			OnFiles: func(jsFiles []crawler.JavaScriptFile) {
+				recordSourceMaps(jsFiles, allFindings)
+				dumpSources(jsFiles, maps.dumper, verbose)
+				for i, jsFindings := range analyzeFiles(jsFiles, jsAnalyzer, threads) {
+					allFindings.AddFindings(jsFindings, jsFiles[i].FileName, jsFiles[i].URL, jsFiles[i].StatusCode)
+				}
+			},
 		}
 
 		jsFiles, err := crawler.CrawlForJavaScript(crawlConfig)
@@ -286,15 +456,8 @@ func processRequestFile(filePath string, httpClient *client.HTTPClient, jsAnalyz
 			}
 		}
 
-		if len(jsFiles) > 0 {
-			if verbose {
-				fmt.Printf("[*] Analyzing %d JavaScript files from crawl...\n", len(jsFiles))
-			}
-
-			for _, jsFile := range jsFiles {
-				jsFindings := jsAnalyzer.Analyze(jsFile.Content, jsFile.URL)
-				allFindings.AddFindings(jsFindings, jsFile.FileName, jsFile.URL, 200)
-			}
+		if len(jsFiles) > 0 && verbose {
+			fmt.Printf("[*] Analyzed %d JavaScript files from crawl\n", len(jsFiles))
 		}
 	}
 
@@ -305,14 +468,44 @@ func processRequestFile(filePath string, httpClient *client.HTTPClient, jsAnalyz
 	return nil
 }
 
+// keepAuthContext adopts the cookie and authorization headers of a request
+// file unless they were given on the command line, so JavaScript fetched
+// while crawling and exported requests use the same session
+func keepAuthContext(config *client.Config, headers map[string]string) {
+	for name, value := range headers {
+		switch strings.ToLower(name) {
+		case "cookie":
+			if config.Cookie == "" {
+				config.Cookie = value
+			}
+		case "authorization":
+			if config.Headers == nil {
+				config.Headers = make(map[string]string)
+			}
+			if _, exists := config.Headers[name]; !exists {
+				config.Headers[name] = value
+			}
+		}
+	}
+}
+
 // processCrawl crawls a URL to find and analyze all JavaScript files
-func processCrawl(targetURL string, httpClient *client.HTTPClient, jsAnalyzer *analyzer.Analyzer, allFindings *types.AggregatedFindings, verbose bool) error {
+func processCrawl(targetURL string, httpClient *client.HTTPClient, jsAnalyzer *analyzer.Analyzer, allFindings *types.AggregatedFindings, maps sourceMapOptions, threads int, verbose bool) error {
 	crawlConfig := &crawler.Config{
 		TargetURL:       targetURL,
-		HTTPClient:      httpClient.Client,
-		FetchURL:        httpClient.FetchURL,
+		Fetch:           httpClient.Fetch,
 		IncludeExternal: false,
+		FetchSources:    maps.fetch,
+		Threads:         threads,
 		Verbose:         verbose,
+		// Analyze each crawl level as soon as it is downloaded
+		OnFiles: func(jsFiles []crawler.JavaScriptFile) {
+			recordSourceMaps(jsFiles, allFindings)
+			dumpSources(jsFiles, maps.dumper, verbose)
+			for i, findings := range analyzeFiles(jsFiles, jsAnalyzer, threads) {
+				allFindings.AddFindings(findings, jsFiles[i].URL, jsFiles[i].URL, jsFiles[i].StatusCode)
+			}
+		},
 	}
 
 	jsFiles, err := crawler.CrawlForJavaScript(crawlConfig)
@@ -321,13 +514,111 @@ func processCrawl(targetURL string, httpClient *client.HTTPClient, jsAnalyzer *a
 	}
 
 	if verbose {
-		fmt.Printf("[*] Analyzing %d JavaScript files...\n", len(jsFiles))
+		fmt.Printf("[*] Analyzed %d JavaScript files\n", len(jsFiles))
 	}
 
-	for _, jsFile := range jsFiles {
-		findings := jsAnalyzer.Analyze(jsFile.Content, jsFile.URL)
-		allFindings.AddFindings(findings, jsFile.URL, jsFile.URL, 200)
+	return nil
+}
+
+// analyzeFiles analyzes crawled JavaScript files concurrently and returns
+// their findings in the same order as jsFiles
+func analyzeFiles(jsFiles []crawler.JavaScriptFile, jsAnalyzer *analyzer.Analyzer, threads int) []*types.Findings {
+	results := make([]*types.Findings, len(jsFiles))
+
+	pool.Run(len(jsFiles), threads, func(i int) {
+		results[i] = jsAnalyzer.Analyze(jsFiles[i].Content, jsFiles[i].URL)
+		if jsFiles[i].SourceMap != nil {
+			mapToOriginal(results[i], jsFiles[i].SourceMap)
+		}
+	})
+
+	return results
+}
+
+// sourceMapOptions holds what to do with the source maps found while crawling
+type sourceMapOptions struct {
+	// dumper writes the original sources to disk, if set
+	dumper *sourcemap.Dumper
+	// fetch downloads the original sources maps do not embed
+	fetch bool
+}
+
+// recordSourceMaps adds the source map downloads of files as sources with
+// their status code. Misses of conventional file.js.map names are left out,
+// as almost every script without a map has one.
+func recordSourceMaps(jsFiles []crawler.JavaScriptFile, allFindings *types.AggregatedFindings) {
+	for _, file := range jsFiles {
+		for _, attempt := range file.SourceMapAttempts {
+			if attempt.Discovery == sourcemap.DiscoveryConvention && attempt.StatusCode == 404 {
+				continue
+			}
+			allFindings.AddFindings(types.NewFindings(false), attempt.URL, attempt.URL, attempt.StatusCode)
+		}
+	}
+}
+
+// dumpSources writes the original sources of the files' source maps to disk
+func dumpSources(jsFiles []crawler.JavaScriptFile, dumper *sourcemap.Dumper, verbose bool) {
+	if dumper == nil {
+		return
+	}
+	for _, file := range jsFiles {
+		if file.SourceMap == nil {
+			continue
+		}
+		written, err := dumper.Dump(file.URL, file.SourceMap)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[!] Error writing sources of %s: %v\n", file.URL, err)
+		}
+		if verbose && written > 0 {
+			fmt.Printf("[+] Wrote %d original sources of %s\n", written, file.URL)
+		}
+	}
+}
+
+// mapToOriginal records the original position of findings in a bundle
+// according to its source map
+func mapToOriginal(findings *types.Findings, sourceMap *sourcemap.SourceMap) {
+	for i := range findings.Items {
+		loc := &findings.Items[i].Location
+		if loc.Line == 0 {
+			continue
+		}
+		if position, ok := sourceMap.OriginalPosition(loc.Line, loc.Column); ok {
+			loc.Original = &types.OriginalLocation{
+				Source: position.Source,
+				Line:   position.Line,
+				Column: position.Column,
+				Name:   position.Name,
+			}
+		}
+	}
+}
+
+// headerFlags collects repeated -H "Name: value" flags
+type headerFlags struct {
+	values map[string]string
+}
+
+// String returns the headers as given
+func (h *headerFlags) String() string {
+	var pairs []string
+	for name, value := range h.values {
+		pairs = append(pairs, name+": "+value)
 	}
+	return strings.Join(pairs, ", ")
+}
 
+// Set parses a single "Name: value" header
+func (h *headerFlags) Set(header string) error {
+	name, value, ok := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("invalid header %q (use \"Name: value\")", header)
+	}
+	if h.values == nil {
+		h.values = make(map[string]string)
+	}
+	h.values[name] = strings.TrimSpace(value)
 	return nil
 }
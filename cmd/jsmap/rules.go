@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/0xhkx0/jsmap/pkg/analyzer"
+)
+
+// loadRuleSets returns the built-in rule sets merged with the custom rules
+// found at rulesPath, if any
+func loadRuleSets(rulesPath string, thresholds analyzer.EntropyThresholds) ([]*analyzer.RuleSet, error) {
+	ruleSets := analyzer.DefaultRuleSetsWithThresholds(thresholds)
+	if rulesPath == "" {
+		return ruleSets, nil
+	}
+
+	custom, err := analyzer.LoadRules(rulesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzer.MergeRuleSets(ruleSets, custom), nil
+}
+
+// runRulesCommand implements the `jsmap rules` subcommands and returns the exit code
+func runRulesCommand(args []string) int {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintf(os.Stderr, `Usage:
+  jsmap rules validate [-rules <file|dir>] [-v]
+
+Checks every built-in and custom rule against its positive and negative examples.
+`)
+		return 1
+	}
+
+	flags := flag.NewFlagSet("rules validate", flag.ExitOnError)
+	rulesPath := flags.String("rules", "", "Custom rule file or directory (YAML/JSON)")
+	verbose := flags.Bool("v", false, "Verbose output")
+	flags.Parse(args[1:])
+
+	// Also accept the rule path as a positional argument
+	if *rulesPath == "" && flags.NArg() > 0 {
+		*rulesPath = flags.Arg(0)
+	}
+
+	ruleSets, err := loadRuleSets(*rulesPath, analyzer.DefaultEntropyThresholds())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading rules: %v\n", err)
+		return 1
+	}
+
+	rules, examples, failures := 0, 0, 0
+	for _, ruleSet := range ruleSets {
+		for _, rule := range ruleSet.Rules {
+			rules++
+			examples += len(rule.Examples.Positive) + len(rule.Examples.Negative)
+
+			errs := rule.CheckExamples(ruleSet.Search)
+			for _, err := range errs {
+				fmt.Printf("[!] %v\n", err)
+			}
+			failures += len(errs)
+
+			if *verbose && len(errs) == 0 {
+				fmt.Printf("[+] %s (%d positive, %d negative)\n", rule.ID, len(rule.Examples.Positive), len(rule.Examples.Negative))
+			}
+		}
+	}
+
+	fmt.Printf("[*] Checked %d rules, %d examples, %d failures\n", rules, examples, failures)
+	if failures > 0 {
+		return 1
+	}
+	return 0
+}
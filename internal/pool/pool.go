@@ -0,0 +1,54 @@
+// Package pool runs work over a bounded number of goroutines, giving every
+// concurrent stage of jsmap the same -t semantics
+package pool
+
+import "sync"
+
+// Run calls work for every index in [0, n) using at most threads goroutines.
+// A threads value below 1 runs the work on a single goroutine.
+func Run(n, threads int, work func(i int)) {
+	if threads < 1 {
+		threads = 1
+	}
+	if threads > n {
+		threads = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < threads; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				work(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// RunOrdered is Run that also calls merge for every index in increasing
+// order, each as soon as work has finished for it and for every lower index.
+// Calls to merge never overlap.
+func RunOrdered(n, threads int, work, merge func(i int)) {
+	var mu sync.Mutex
+	done := make([]bool, n)
+	next := 0
+	Run(n, threads, func(i int) {
+		work(i)
+
+		mu.Lock()
+		defer mu.Unlock()
+		done[i] = true
+		for next < n && done[next] {
+			merge(next)
+			next++
+		}
+	})
+}
@@ -2,349 +2,314 @@ package analyzer
 
 import (
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/0xhkx0/jsmap/pkg/lexer"
 	"github.com/0xhkx0/jsmap/pkg/types"
 )
 
-// Analyzer performs JavaScript analysis
+// Analyzer performs JavaScript analysis.
+//
+// An Analyzer holds no per-file state: everything produced while analyzing
+// one piece of content lives in a scan that is local to the Analyze call.
+// A single Analyzer can therefore be shared by any number of goroutines.
 type Analyzer struct {
-	verbose    bool
-	isMinified bool
+	verbose  bool
+	ruleSets []*RuleSet
 }
 
-// NewAnalyzer creates a new analyzer
+// scan holds the state of a single Analyze call
+type scan struct {
+	content    string
+	source     string
+	tokenized  bool
+	tokens     []lexer.Token
+	literals   []searchText
+	lineStarts []int
+	folder     *folder
+	findings   *types.Findings
+	// signatureSpans are the [start, end) offsets of values matched by
+	// signature secret rules, which generic secret rules skip
+	signatureSpans [][2]int
+	// wholeLiterals are the literals that are not template pieces
+	wholeLiterals []searchText
+}
+
+// snippetRadius is how many bytes of context are kept on each side of a finding
+const snippetRadius = 60
+
+// NewAnalyzer creates a new analyzer using the built-in rule sets
 func NewAnalyzer(verbose bool) *Analyzer {
-	return &Analyzer{verbose: verbose, isMinified: false}
+	return NewAnalyzerWithRules(verbose, DefaultRuleSets())
 }
 
-// Analyze analyzes JavaScript content
+// NewAnalyzerWithRules creates a new analyzer using the given rule sets
+func NewAnalyzerWithRules(verbose bool, ruleSets []*RuleSet) *Analyzer {
+	return &Analyzer{verbose: verbose, ruleSets: ruleSets}
+}
+
+// Analyze analyzes JavaScript content. It is safe for concurrent use.
 func (a *Analyzer) Analyze(content string, source string) *types.Findings {
-	findings := types.NewFindings(a.verbose)
+	s := &scan{
+		content:  content,
+		source:   source,
+		findings: types.NewFindings(a.verbose),
+	}
 
-	// Detect if minified
-	a.isMinified = a.detectMinified(content)
-	if a.verbose && a.isMinified {
+	// Minification is only reported, matching does not depend on it
+	if a.verbose && a.detectMinified(content) {
 		fmt.Printf("[*] Detected minified JavaScript\n")
 	}
 
-	// Extract endpoints
-	a.extractEndpoints(content, source, findings)
+	for _, ruleSet := range a.ruleSets {
+		a.applyRuleSet(s, ruleSet)
+	}
+	a.extractDynamicEndpoints(s)
+	a.extractRequests(s)
 
-	// Extract URLs
-	a.extractURLs(content, source, findings)
+	return s.findings
+}
 
-	// Extract secrets
-	a.extractSecrets(content, source, findings)
+// searchText is a text rule sets are matched against. A match at i in text
+// is at offset+i in the original content, unless the text was decoded from
+// escapes (exact is false) and every match is located at offset.
+type searchText struct {
+	text   string
+	offset int
+	exact  bool
+}
 
-	// Extract emails
-	a.extractEmails(content, source, findings)
+// position returns the offset in the original content of a match at i
+func (t searchText) position(i int) int {
+	if t.exact {
+		return t.offset + i
+	}
+	return t.offset
+}
 
-	// Extract files
-	a.extractFiles(content, source, findings)
+// searchTexts returns the texts of content a rule set with the given search
+// mode is matched against
+func searchTexts(content string, tokens []lexer.Token, search int) []searchText {
+	if search == SearchSource {
+		return []searchText{{text: content, exact: true}}
+	}
+	return literalTexts(tokens, search == SearchWholeLiterals)
+}
+
+// literalTexts returns every string and template literal token as a text
+// wrapped in its quote, so `foo${x}bar` yields `foo` and `bar` unless whole
+// is set, which skips such pieces. Literals without escapes are kept
+// verbatim to preserve their offsets.
+func literalTexts(tokens []lexer.Token, whole bool) []searchText {
+	var texts []searchText
+	for _, tok := range tokens {
+		if !tok.IsLiteral() || whole && tok.Kind != lexer.String && tok.Kind != lexer.Template {
+			continue
+		}
+
+		quote := "`"
+		if tok.Kind == lexer.String {
+			quote = tok.Raw[:1]
+		}
+		text := searchText{text: quote + tok.Value + quote, offset: tok.Offset, exact: true}
+		if !strings.HasPrefix(tok.Raw[1:], tok.Value) {
+			text.offset++
+			text.exact = false
+		}
+		texts = append(texts, text)
+	}
+	return texts
+}
 
-	return findings
+// tokenize tokenizes the content once per scan
+func (s *scan) tokenize() {
+	if !s.tokenized {
+		s.tokenized = true
+		s.tokens = lexer.Tokenize(s.content)
+		s.literals = literalTexts(s.tokens, false)
+		s.wholeLiterals = literalTexts(s.tokens, true)
+	}
 }
 
-// extractEndpoints finds API endpoints
-func (a *Analyzer) extractEndpoints(content string, source string, findings *types.Findings) {
-	// Use beautified version for minified code
-	searchContent := content
-	if a.isMinified {
-		searchContent = a.beautifyMinified(content)
-	}
-	patterns := []string{
-		// API endpoints (supports quotes, backticks, and variable assignments)
-		`["'\x60]((?:https?:)?//[^"'\x60]+/api/[a-zA-Z0-9/_-]+)["'\x60]`,
-		`["'\x60](/api/v?\d*/[a-zA-Z0-9/_-]{2,})["'\x60]`,
-		`["'\x60](/v\d+/[a-zA-Z0-9/_-]{2,})["'\x60]`,
-		`["'\x60](/rest/[a-zA-Z0-9/_-]{2,})["'\x60]`,
-		`["'\x60](/graphql[a-zA-Z0-9/_-]*)["'\x60]`,
-
-		// OAuth/Auth endpoints
-		`["'\x60](/oauth[0-9]*/[a-zA-Z0-9/_-]+)["'\x60]`,
-		`["'\x60](/auth[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/login[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/logout[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/token[a-zA-Z0-9/_-]*)["'\x60]`,
-
-		// Sensitive paths
-		`["'\x60](/admin[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/dashboard[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/internal[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/debug[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/config[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/backup[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/private[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/upload[a-zA-Z0-9/_-]*)["'\x60]`,
-		`["'\x60](/download[a-zA-Z0-9/_-]*)["'\x60]`,
-
-		// Well-known paths
-		`["'\x60](/\.well-known/[a-zA-Z0-9/_-]+)["'\x60]`,
-		`["'\x60](/idp/[a-zA-Z0-9/_-]+)["'\x60]`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindAllStringSubmatch(searchContent, -1)
-		for _, match := range matches {
-			if len(match) >= 2 {
-				value := strings.TrimSpace(match[1])
-				if a.isValidEndpoint(value) {
-					key := "endpoints:" + value
-					if !findings.SeenKeys[key] {
-						findings.SeenKeys[key] = true
-						findings.Endpoints[value] = true
-						if a.verbose {
-							fmt.Printf("[+] Endpoint: %s\n", value)
+// searchContents returns the texts a rule set should be matched against
+func (s *scan) searchContents(search int) []searchText {
+	if search == SearchSource {
+		return searchTexts(s.content, nil, search)
+	}
+	s.tokenize()
+	if search == SearchWholeLiterals {
+		return s.wholeLiterals
+	}
+	return s.literals
+}
+
+// applyRuleSet runs every rule of a rule set against the scan
+func (a *Analyzer) applyRuleSet(s *scan, ruleSet *RuleSet) {
+	for _, search := range s.searchContents(ruleSet.Search) {
+		for _, rule := range ruleSet.Rules {
+			for _, m := range rule.matches(search.text) {
+				offset := search.position(m.offset)
+				if rule.Category == types.CategorySecrets {
+					span := [2]int{offset, offset + len(m.value)}
+					if rule.Confidence != nil {
+						if s.signatureMatched(span) {
+							continue
 						}
+					} else {
+						s.signatureSpans = append(s.signatureSpans, span)
 					}
 				}
+				a.record(s, rule, m.value, s.locate(offset, len(m.value)))
 			}
 		}
 	}
 }
 
-// extractURLs finds full URLs
-func (a *Analyzer) extractURLs(content string, source string, findings *types.Findings) {
-	// Use beautified version for minified code
-	searchContent := content
-	if a.isMinified {
-		searchContent = a.beautifyMinified(content)
-	}
-
-	patterns := []string{
-		`["'](https?://[^\s"'<>]{10,})["']`,
-		`["'](wss?://[^\s"'<>]{10,})["']`,
-		`["'](sftp://[^\s"'<>]{10,})["']`,
-		`(https?://[a-zA-Z0-9.-]+\.s3[a-zA-Z0-9.-]*\.amazonaws\.com[^\s"'<>]*)`,
-		`(https?://[a-zA-Z0-9.-]+\.blob\.core\.windows\.net[^\s"'<>]*)`,
-		`(https?://storage\.googleapis\.com/[^\s"'<>]*)`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindAllStringSubmatch(searchContent, -1)
-		for _, match := range matches {
-			value := strings.TrimSpace(match[len(match)-1])
-			if a.isValidURL(value) {
-				key := "urls:" + value
-				if !findings.SeenKeys[key] {
-					findings.SeenKeys[key] = true
-					findings.URLs[value] = true
-					if a.verbose {
-						fmt.Printf("[+] URL: %s\n", value)
-					}
-				}
-			}
+// signatureMatched reports whether span overlaps a value already matched by
+// a signature secret rule
+func (s *scan) signatureMatched(span [2]int) bool {
+	for _, matched := range s.signatureSpans {
+		if span[0] < matched[1] && matched[0] < span[1] {
+			return true
 		}
 	}
+	return false
 }
 
-// extractSecrets finds API keys and tokens
-func (a *Analyzer) extractSecrets(content string, source string, findings *types.Findings) {
-	// Search in both original and beautified content
-	searchContents := []string{content}
-	if a.isMinified {
-		searchContents = append(searchContents, a.beautifyMinified(content))
-	}
-
-	secretPatterns := map[string]string{
-		`AKIA[0-9A-Z]{16}`:                                   "AWS Key",
-		`AIza[0-9A-Za-z\-_]{35}`:                             "Google API",
-		`sk_live_[0-9a-zA-Z]{24,}`:                           "Stripe Live",
-		`ghp_[0-9a-zA-Z]{36}`:                                "GitHub PAT",
-		`xox[baprs]-[0-9a-zA-Z\-]{10,48}`:                    "Slack Token",
-		`eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]+`: "JWT",
-		`-----BEGIN (?:RSA |EC )?PRIVATE KEY-----`:           "Private Key",
-		`mongodb(?:\+srv)?://[^\s"'<>]+`:                     "MongoDB",
-		`postgres(?:ql)?://[^\s"'<>]+`:                       "PostgreSQL",
-	}
-
-	seenSecrets := make(map[string]bool)
-
-	for _, searchContent := range searchContents {
-		for pattern, secretType := range secretPatterns {
-			re := regexp.MustCompile(pattern)
-			matches := re.FindAllString(searchContent, -1)
-			for _, value := range matches {
-				if seenSecrets[value] {
-					continue
-				}
-				seenSecrets[value] = true
+// locate converts a byte offset in the original content into a Location.
+// A negative offset yields an empty Location (Line 0).
+func (s *scan) locate(offset, length int) types.Location {
+	if offset < 0 {
+		return types.Location{}
+	}
 
-				if a.isValidSecret(value) {
-					masked := value
-					if len(value) > 20 {
-						masked = value[:10] + "..." + value[len(value)-4:]
-					}
-					key := "secrets:" + masked
-					if !findings.SeenKeys[key] {
-						findings.SeenKeys[key] = true
-						findings.Secrets = append(findings.Secrets, types.Finding{
-							Category: "secrets",
-							Value:    masked + " (" + secretType + ")",
-							Source:   source,
-						})
-						if a.verbose {
-							fmt.Printf("[!] Secret: %s (%s)\n", masked, secretType)
-						}
-					}
-				}
+	if s.lineStarts == nil {
+		s.lineStarts = []int{0}
+		for i := 0; i < len(s.content); i++ {
+			if s.content[i] == '\n' {
+				s.lineStarts = append(s.lineStarts, i+1)
 			}
 		}
 	}
-}
 
-// extractEmails finds email addresses
-func (a *Analyzer) extractEmails(content string, source string, findings *types.Findings) {
-	// Search in both original and beautified content for better minified support
-	searchContents := []string{content}
-	if a.isMinified {
-		searchContents = append(searchContents, a.beautifyMinified(content))
+	line := sort.Search(len(s.lineStarts), func(i int) bool { return s.lineStarts[i] > offset })
+	lineStart := s.lineStarts[line-1]
+
+	return types.Location{
+		Offset:  offset,
+		Line:    line,
+		Column:  utf8.RuneCountInString(s.content[lineStart:offset]) + 1,
+		Snippet: snippet(s.content, offset, offset+length),
 	}
+}
 
-	// Multiple patterns to catch different email formats
-	patterns := []string{
-		`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,6}`,                   // Standard
-		`["']([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,6})["']`,        // Quoted
-		`=["']([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,6})["']`,       // Variable assignment
+// snippet returns the content around [start, end) bounded by snippetRadius,
+// cut on rune boundaries and with line breaks flattened
+func snippet(content string, start, end int) string {
+	from := start - snippetRadius
+	if from < 0 {
+		from = 0
+	}
+	to := end + snippetRadius
+	if to > len(content) {
+		to = len(content)
+	}
+	for from > 0 && !utf8.RuneStart(content[from]) {
+		from--
+	}
+	for to < len(content) && !utf8.RuneStart(content[to]) {
+		to++
 	}
 
-	seenEmails := make(map[string]bool)
+	text := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ").Replace(content[from:to])
+	return strings.TrimSpace(text)
+}
 
-	for _, searchContent := range searchContents {
-		for _, pattern := range patterns {
-			re := regexp.MustCompile(pattern)
-			matches := re.FindAllStringSubmatch(searchContent, -1)
+// record stores a matched value as a finding of the rule's category
+func (a *Analyzer) record(s *scan, rule *Rule, value string, loc types.Location) {
+	a.add(s, newFinding(s, rule, value, loc))
+}
 
-			for _, match := range matches {
-				// Get the email from capture group if exists, otherwise use full match
-				var value string
-				if len(match) > 1 && match[1] != "" {
-					value = match[1]
-				} else {
-					value = match[0]
-				}
+// newFinding builds the finding of a rule for a matched value
+func newFinding(s *scan, rule *Rule, value string, loc types.Location) types.Finding {
+	finding := types.Finding{
+		Category:   rule.Category,
+		Type:       rule.Name,
+		RuleID:     rule.ID,
+		Value:      value,
+		Severity:   rule.Severity,
+		Confidence: rule.confidence(value),
+		Source:     s.source,
+		Location:   loc,
+		Tags:       rule.Tags,
+	}
+	if rule.Category == types.CategorySecrets {
+		finding.Masked = types.MaskSecret(value)
+	}
+	return finding
+}
 
-				// Skip if already seen in this search
-				if seenEmails[value] {
-					continue
-				}
-				seenEmails[value] = true
-
-				if a.isValidEmail(value) {
-					key := "emails:" + value
-					if !findings.SeenKeys[key] {
-						findings.SeenKeys[key] = true
-						findings.Emails[value] = true
-						if a.verbose {
-							fmt.Printf("[+] Email: %s\n", value)
-						}
-					}
-				}
-			}
-		}
+// add stores a finding in the scan and logs it when new
+func (a *Analyzer) add(s *scan, finding types.Finding) {
+	if s.findings.Add(finding) {
+		a.log(finding)
 	}
 }
 
-// extractFiles finds file references
-func (a *Analyzer) extractFiles(content string, source string, findings *types.Findings) {
-	// Use beautified version for minified code
-	searchContent := content
-	if a.isMinified {
-		searchContent = a.beautifyMinified(content)
-	}
-
-	// Support quotes, backticks (template literals)
-	pattern := `["'\x60]([a-zA-Z0-9_/.-]+\.(?:sql|csv|xlsx|xls|json|xml|yaml|yml|txt|log|conf|config|cfg|ini|env|bak|backup|old|orig|copy|key|pem|crt|cer|p12|pfx|doc|docx|pdf|zip|tar|gz|rar|7z|sh|bat|ps1|py|rb|pl))["'\x60]`
-	re := regexp.MustCompile(pattern)
-	matches := re.FindAllStringSubmatch(searchContent, -1)
-
-	for _, match := range matches {
-		if len(match) >= 2 {
-			value := strings.TrimSpace(match[1])
-			if a.isValidFile(value) {
-				key := "files:" + value
-				if !findings.SeenKeys[key] {
-					findings.SeenKeys[key] = true
-					findings.Files[value] = true
-					if a.verbose {
-						fmt.Printf("[+] File: %s\n", value)
-					}
-				}
-			}
-		}
+// merge stores a finding in the scan without counting another occurrence
+// of a value that is already recorded, and logs it when new
+func (a *Analyzer) merge(s *scan, finding types.Finding) {
+	if s.findings.Merge(finding) {
+		a.log(finding)
+	}
+}
+
+// log prints a new finding in verbose mode
+func (a *Analyzer) log(finding types.Finding) {
+	if !a.verbose {
+		return
+	}
+
+	switch finding.Category {
+	case types.CategorySecrets:
+		fmt.Printf("[!] Secret: %s (%s, %s confidence)\n", finding.Masked, finding.Type, finding.Confidence)
+	case types.CategoryEndpoints:
+		fmt.Printf("[+] Endpoint: %s\n", finding.Value)
+	case types.CategoryURLs:
+		fmt.Printf("[+] URL: %s\n", finding.Value)
+	case types.CategoryEmails:
+		fmt.Printf("[+] Email: %s\n", finding.Value)
+	case types.CategoryFiles:
+		fmt.Printf("[+] File: %s\n", finding.Value)
 	}
 }
 
 // Validation functions
 
+// endpointNoiseStrings are exact values rejected as endpoints
+var endpointNoiseStrings = map[string]bool{
+	"http://":  true,
+	"https://": true,
+	"/a":       true,
+	"/P":       true,
+	"/R":       true,
+	"/V":       true,
+	"/W":       true,
+}
+
 // isValidEndpoint validates endpoint
-func (a *Analyzer) isValidEndpoint(value string) bool {
+func isValidEndpoint(value string) bool {
 	if len(value) < 3 {
 		return false
 	}
 
 	// Reject common noise strings
-	noiseStrings := map[string]bool{
-		"http://":  true,
-		"https://": true,
-		"/a":       true,
-		"/P":       true,
-		"/R":       true,
-		"/V":       true,
-		"/W":       true,
-	}
-
-	if noiseStrings[value] {
+	if endpointNoiseStrings[value] {
 		return false
 	}
 
-	// Check noise patterns
-	noisePatterns := []string{
-		`^\.\.?/`,                      // Starts with ./ or ../
-		`^[a-z]{2}(-[a-z]{2})?\.js$`,  // Locale files
-		`^[a-z]{2}(-[a-z]{2})?$`,      // Just locale
-		`-xform$`,                      // Excel xform
-		`^sha\d*$`,                     // Crypto modules
-		`^aes$|^des$|^md5$`,            // Crypto
-		`^/[A-Z][a-z]+\s`,             // PDF structures
-		`^/[A-Z][a-z]+$`,              // PDF objects
-		`^\d+ \d+ R$`,                  // PDF refs
-		`^xl/`,                         // Excel internal
-		`^docProps/`,                   // Doc properties
-		`^_rels/`,                      // Relationships
-		`^META-INF/`,                   // Manifest
-		`\.xml$`,                       // XML files
-		`^worksheets/`,                 // Worksheets
-		`^theme/`,                      // Theme
-		`^webpack`,                     // Bundler
-		`^zone\.js$`,                   // JS modules
-		`^readable-stream/`,            // Modules
-		`^process/`,                    // Modules
-		`^stream/`,                     // Modules
-		`^buffer$`,                     // Modules
-		`^events$`,                     // Modules
-		`^util$`,                       // Modules
-		`^path$`,                       // Modules
-		`^\+`,                          // Starts with +
-		`^\$\{`,                        // Template literal
-		`^#`,                           // Fragment
-		`^\?\ref=`,                     // Query param
-		`^/[a-z]$`,                     // Single letter
-		`^/[A-Z]$`,                     // Single letter
-		`^http://$`,                    // Empty
-		`_ngcontent`,                   // Angular
-	}
-
-	for _, pattern := range noisePatterns {
-		if match, _ := regexp.MatchString(pattern, value); match {
-			return false
-		}
-	}
-
 	// Must start with / and have some path
 	if !strings.HasPrefix(value, "/") {
 		return false
@@ -360,7 +325,7 @@ func (a *Analyzer) isValidEndpoint(value string) bool {
 }
 
 // isValidURL validates URL
-func (a *Analyzer) isValidURL(value string) bool {
+func isValidURL(value string) bool {
 	if len(value) < 15 {
 		return false
 	}
@@ -407,7 +372,7 @@ func (a *Analyzer) isValidURL(value string) bool {
 }
 
 // isValidSecret validates secret
-func (a *Analyzer) isValidSecret(value string) bool {
+func isValidSecret(value string) bool {
 	if len(value) < 10 {
 		return false
 	}
@@ -428,12 +393,12 @@ func (a *Analyzer) isValidSecret(value string) bool {
 func (a *Analyzer) detectMinified(content string) bool {
 	// Check various indicators of minification
 	lines := strings.Split(content, "\n")
-	
+
 	// Minified files typically have very few lines with very long content
 	if len(lines) < 3 && len(content) > 5000 {
 		return true
 	}
-	
+
 	// Check if most lines are very long (> 200 chars)
 	longLines := 0
 	nonEmptyLines := 0
@@ -446,11 +411,11 @@ func (a *Analyzer) detectMinified(content string) bool {
 			}
 		}
 	}
-	
+
 	if nonEmptyLines > 0 && longLines > nonEmptyLines*2/3 {
 		return true
 	}
-	
+
 	// Check for lack of meaningful comments and spacing
 	commentLines := 0
 	for _, line := range lines {
@@ -459,55 +424,19 @@ func (a *Analyzer) detectMinified(content string) bool {
 			commentLines++
 		}
 	}
-	
+
 	// Minified code usually has few comments
 	if nonEmptyLines > 10 && commentLines < nonEmptyLines/10 {
 		if len(content) > 10000 {
 			return true
 		}
 	}
-	
-	return false
-}
 
-// beautifyMinified adds spaces for better pattern matching in minified code
-func (a *Analyzer) beautifyMinified(content string) string {
-	// Add spaces around common delimiters in minified code to help pattern matching
-	beautified := content
-	
-	// Add space after quotes that precede paths
-	beautified = regexp.MustCompile(`"(/[^"]*?)"`).ReplaceAllString(beautified, `" $1 "`)
-	beautified = regexp.MustCompile(`'(/[^']*?)'`).ReplaceAllString(beautified, `' $1 '`)
-	
-	// Add space after colons (for JSON properties)
-	beautified = strings.ReplaceAll(beautified, "\":", "\" : ")
-	beautified = strings.ReplaceAll(beautified, "':", "' : ")
-	
-	// Add spaces around common API patterns
-	beautified = strings.ReplaceAll(beautified, "/api/", " /api/ ")
-	beautified = strings.ReplaceAll(beautified, "/auth/", " /auth/ ")
-	beautified = strings.ReplaceAll(beautified, "/admin/", " /admin/ ")
-	beautified = strings.ReplaceAll(beautified, "/v1/", " /v1/ ")
-	beautified = strings.ReplaceAll(beautified, "/v2/", " /v2/ ")
-	beautified = strings.ReplaceAll(beautified, "/oauth", " /oauth ")
-	beautified = strings.ReplaceAll(beautified, "/login", " /login ")
-	beautified = strings.ReplaceAll(beautified, "/auth", " /auth ")
-	beautified = strings.ReplaceAll(beautified, "/upload", " /upload ")
-	beautified = strings.ReplaceAll(beautified, "/download", " /download ")
-	beautified = strings.ReplaceAll(beautified, "/config", " /config ")
-	beautified = strings.ReplaceAll(beautified, "/admin", " /admin ")
-	beautified = strings.ReplaceAll(beautified, "/dashboard", " /dashboard ")
-	beautified = strings.ReplaceAll(beautified, "@", " @ ")
-	
-	// Add newlines to help split patterns
-	beautified = strings.ReplaceAll(beautified, ";", ";\n")
-	beautified = strings.ReplaceAll(beautified, ",", ",\n")
-	
-	return beautified
+	return false
 }
 
 // isValidEmail validates email
-func (a *Analyzer) isValidEmail(value string) bool {
+func isValidEmail(value string) bool {
 	if !strings.Contains(value, "@") {
 		return false
 	}
@@ -520,10 +449,10 @@ func (a *Analyzer) isValidEmail(value string) bool {
 	domain := strings.ToLower(parts[len(parts)-1])
 
 	excludedDomains := map[string]bool{
-		"example.com":       true,
-		"test.com":          true,
-		"domain.com":        true,
-		"placeholder.com":   true,
+		"example.com":     true,
+		"test.com":        true,
+		"domain.com":      true,
+		"placeholder.com": true,
 	}
 
 	if excludedDomains[domain] {
@@ -541,7 +470,7 @@ func (a *Analyzer) isValidEmail(value string) bool {
 }
 
 // isValidFile validates file reference
-func (a *Analyzer) isValidFile(value string) bool {
+func isValidFile(value string) bool {
 	if len(value) < 3 {
 		return false
 	}
@@ -0,0 +1,468 @@
+package analyzer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/0xhkx0/jsmap/pkg/lexer"
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// dynamicEndpointRule describes endpoints recovered by folding string
+// concatenations and template literals
+var dynamicEndpointRule = &Rule{
+	ID:       "endpoint-dynamic",
+	Category: types.CategoryEndpoints,
+	Name:     "Dynamic Endpoint",
+	Severity: SeverityInfo,
+	Tags:     []string{"api", "dynamic"},
+}
+
+// maxFoldRounds bounds how often assignments are re-evaluated so constants
+// defined in terms of other constants resolve in any order
+const maxFoldRounds = 3
+
+// maxExpressionSnippet caps the length of an expression used for its snippet
+const maxExpressionSnippet = 120
+
+// part is a piece of a folded string expression: either constant text or an
+// unresolved value named by param
+type part struct {
+	text  string
+	param string
+}
+
+// expression is a folded string expression spanning tokens [start, end)
+type expression struct {
+	parts      []part
+	start, end int
+	// dynamic is set when the expression concatenates several operands or
+	// has template substitutions
+	dynamic bool
+}
+
+// constant returns the value of the expression if every part is constant
+func (e expression) constant() (string, bool) {
+	var b strings.Builder
+	for _, p := range e.parts {
+		if p.param != "" {
+			return "", false
+		}
+		b.WriteString(p.text)
+	}
+	return b.String(), true
+}
+
+// assignment is a name assigned or initialised with the expression at rhs,
+// e.g. `const API = ...`, `this.base = ...` or `{base: ...}`
+type assignment struct {
+	name string
+	rhs  int
+}
+
+// folder folds string expressions over the code tokens of a file. Names
+// assigned a single constant string in the file resolve to it; names with
+// conflicting values stay unresolved.
+type folder struct {
+	tokens    []lexer.Token
+	constants map[string]string
+	ambiguous map[string]bool
+	// objects maps names assigned a single object literal to its "{" token,
+	// or to -1 when assigned several
+	objects map[string]int
+}
+
+// newFolder creates a folder for the code tokens and resolves the file's constants
+func newFolder(tokens []lexer.Token) *folder {
+	f := &folder{
+		tokens:    tokens,
+		constants: make(map[string]string),
+		ambiguous: make(map[string]bool),
+		objects:   make(map[string]int),
+	}
+
+	assignments := f.assignments()
+	for _, a := range assignments {
+		if !f.tokens[a.rhs].Is("{") {
+			continue
+		}
+		if _, exists := f.objects[a.name]; exists {
+			f.objects[a.name] = -1
+			continue
+		}
+		f.objects[a.name] = a.rhs
+	}
+	for round := 0; round < maxFoldRounds; round++ {
+		changed := false
+		for _, a := range assignments {
+			if f.ambiguous[a.name] {
+				continue
+			}
+			expr, ok := f.parse(a.rhs)
+			if !ok || !f.endsStatement(expr.end) {
+				continue
+			}
+			value, ok := expr.constant()
+			if !ok {
+				continue
+			}
+			if previous, exists := f.constants[a.name]; exists {
+				if previous != value {
+					delete(f.constants, a.name)
+					f.ambiguous[a.name] = true
+					changed = true
+				}
+				continue
+			}
+			f.constants[a.name] = value
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return f
+}
+
+// assignments returns every simple assignment and object property in the file
+func (f *folder) assignments() []assignment {
+	var assignments []assignment
+	for i := 0; i+2 < len(f.tokens); i++ {
+		tok, next := f.tokens[i], f.tokens[i+1]
+		switch {
+		case tok.Kind == lexer.Identifier && next.Is("="):
+			assignments = append(assignments, assignment{name: tok.Raw, rhs: i + 2})
+		case (tok.Kind == lexer.Identifier || tok.Kind == lexer.String) && next.Is(":") && i > 0 &&
+			(f.tokens[i-1].Is("{") || f.tokens[i-1].Is(",")):
+			assignments = append(assignments, assignment{name: tok.Value, rhs: i + 2})
+		}
+	}
+	return assignments
+}
+
+// endsStatement reports whether the token at i ends the expression before
+// it, so the expression is the whole assigned value
+func (f *folder) endsStatement(i int) bool {
+	if i >= len(f.tokens) {
+		return true
+	}
+	tok := f.tokens[i]
+	switch tok.Kind {
+	case lexer.Identifier, lexer.Keyword:
+		// Automatic semicolon insertion
+		return true
+	case lexer.Punctuator:
+		switch tok.Raw {
+		case ";", ",", "}", ")", "]":
+			return true
+		}
+	}
+	return false
+}
+
+// expressions returns every dynamic string expression in the file
+func (f *folder) expressions() []expression {
+	var expressions []expression
+	for i := 0; i < len(f.tokens); {
+		if i > 0 && (f.tokens[i-1].Is(".") || f.tokens[i-1].Is("?.")) {
+			i++
+			continue
+		}
+		expr, ok := f.parse(i)
+		if ok && expr.dynamic {
+			expressions = append(expressions, expr)
+			i = expr.end
+			continue
+		}
+		i++
+	}
+	return expressions
+}
+
+// parse folds the + concatenation starting at token i
+func (f *folder) parse(i int) (expression, bool) {
+	expr := expression{start: i}
+	operands := 0
+	for {
+		parts, next, dynamic, ok := f.operand(i)
+		if !ok {
+			break
+		}
+		operands++
+		expr.parts = append(expr.parts, parts...)
+		expr.end = next
+		expr.dynamic = expr.dynamic || dynamic || operands > 1
+
+		if next+1 >= len(f.tokens) || !f.tokens[next].Is("+") {
+			break
+		}
+		i = next + 1
+	}
+	return expr, operands > 0
+}
+
+// operand folds a single operand at token i and returns its parts, the
+// index of the token after it and whether it has template substitutions
+func (f *folder) operand(i int) ([]part, int, bool, bool) {
+	if i >= len(f.tokens) {
+		return nil, i, false, false
+	}
+
+	tok := f.tokens[i]
+	switch {
+	case tok.Kind == lexer.String, tok.Kind == lexer.Template, tok.Kind == lexer.Number:
+		return []part{{text: tok.Value}}, i + 1, false, true
+	case tok.Kind == lexer.TemplateHead:
+		parts, next := f.template(i)
+		return parts, next, true, true
+	case tok.Is("("):
+		expr, ok := f.parse(i + 1)
+		if !ok || expr.end >= len(f.tokens) || !f.tokens[expr.end].Is(")") {
+			return nil, i, false, false
+		}
+		return expr.parts, expr.end + 1, expr.dynamic, true
+	case tok.Kind == lexer.Identifier, tok.Is("this"):
+		parts, next := f.reference(i)
+		return parts, next, false, true
+	}
+	return nil, i, false, false
+}
+
+// template folds a template literal with substitutions starting at the
+// TemplateHead token i
+func (f *folder) template(i int) ([]part, int) {
+	parts := []part{{text: f.tokens[i].Value}}
+	start := i + 1
+	depth := 0
+	for j := start; j < len(f.tokens); j++ {
+		switch f.tokens[j].Kind {
+		case lexer.TemplateHead:
+			depth++
+			continue
+		case lexer.TemplateTail:
+			if depth > 0 {
+				depth--
+				continue
+			}
+		case lexer.TemplateMiddle:
+			if depth > 0 {
+				continue
+			}
+		default:
+			continue
+		}
+
+		parts = append(parts, f.substitution(start, j)...)
+		parts = append(parts, part{text: f.tokens[j].Value})
+		if f.tokens[j].Kind == lexer.TemplateTail {
+			return parts, j + 1
+		}
+		start = j + 1
+	}
+	return parts, len(f.tokens)
+}
+
+// substitution folds the tokens [start, end) of a ${} substitution
+func (f *folder) substitution(start, end int) []part {
+	if expr, ok := f.parse(start); ok && expr.end == end {
+		return expr.parts
+	}
+	return []part{{param: f.name(start, end)}}
+}
+
+// reference resolves an identifier, member chain or call at token i.
+// Known constants resolve to their value, anything else becomes a param
+// named after the last property, or after the single argument of a call
+// such as encodeURIComponent(userId).
+func (f *folder) reference(i int) ([]part, int) {
+	name := f.tokens[i].Raw
+	j := i + 1
+	for j+1 < len(f.tokens) && (f.tokens[j].Is(".") || f.tokens[j].Is("?.")) &&
+		(f.tokens[j+1].Kind == lexer.Identifier || f.tokens[j+1].Kind == lexer.Keyword) {
+		name = f.tokens[j+1].Raw
+		j += 2
+	}
+
+	if j < len(f.tokens) && (f.tokens[j].Is("(") || f.tokens[j].Is("[")) {
+		end := f.closing(j)
+		if f.tokens[j].Is("(") {
+			if expr, ok := f.parse(j + 1); ok && expr.end == end && len(expr.parts) == 1 && expr.parts[0].param != "" {
+				name = expr.parts[0].param
+			}
+		}
+		return []part{{param: name}}, end + 1
+	}
+
+	if value, ok := f.constants[name]; ok {
+		return []part{{text: value}}, j
+	}
+	return []part{{param: name}}, j
+}
+
+// closing returns the index of the bracket closing the one at token i
+func (f *folder) closing(i int) int {
+	depth := 0
+	for j := i; j < len(f.tokens); j++ {
+		switch {
+		case f.tokens[j].Is("("), f.tokens[j].Is("["), f.tokens[j].Is("{"):
+			depth++
+		case f.tokens[j].Is(")"), f.tokens[j].Is("]"), f.tokens[j].Is("}"):
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(f.tokens) - 1
+}
+
+// name returns a param name for the tokens [start, end): the last
+// identifier in them, or "param"
+func (f *folder) name(start, end int) string {
+	for j := end - 1; j >= start; j-- {
+		if f.tokens[j].Kind == lexer.Identifier {
+			return f.tokens[j].Raw
+		}
+	}
+	return "param"
+}
+
+// endpointTemplate normalizes folded parts into an endpoint template such as
+// /users/{id}/orders and returns its params. An unresolved base before the
+// first path segment is dropped and the query string and fragment are cut.
+// Unless the parts are known to be a request URL (strict is false), a lone
+// segment after a dropped base is rejected.
+func endpointTemplate(parts []part, strict bool) (string, []string, bool) {
+	// Merge adjacent text so the checks below see whole segments
+	var merged []part
+	for _, p := range parts {
+		if p.param == "" && len(merged) > 0 && merged[len(merged)-1].param == "" {
+			merged[len(merged)-1].text += p.text
+			continue
+		}
+		if p.param == "" && p.text == "" {
+			continue
+		}
+		merged = append(merged, p)
+	}
+
+	droppedBase := false
+	if len(merged) > 1 && merged[0].param != "" && strings.HasPrefix(merged[1].text, "/") {
+		merged = merged[1:]
+		droppedBase = true
+	}
+	if len(merged) == 0 || merged[0].param != "" {
+		return "", nil, false
+	}
+
+	var b strings.Builder
+	var params []string
+	seen := make(map[string]int)
+	static := ""
+	for _, p := range merged {
+		if p.param == "" {
+			text := p.text
+			if cut := strings.IndexAny(text, "?#"); cut >= 0 {
+				b.WriteString(text[:cut])
+				static += text[:cut]
+				break
+			}
+			b.WriteString(text)
+			static += text
+			continue
+		}
+
+		name := p.param
+		seen[name]++
+		if seen[name] > 1 {
+			name += strconv.Itoa(seen[name])
+		}
+		params = append(params, name)
+		b.WriteString("{" + name + "}")
+	}
+
+	value := b.String()
+	if !isValidEndpointTemplate(value, static) {
+		return "", nil, false
+	}
+	// A lone segment appended to an unknown value, e.g. path + "/type", is
+	// more often a JSON pointer or file path than an endpoint
+	if strict && droppedBase && strings.Count(strings.Trim(value, "/"), "/") == 0 {
+		return "", nil, false
+	}
+	return value, params, true
+}
+
+// isValidEndpointTemplate validates a folded endpoint template given the
+// concatenation of its constant text
+func isValidEndpointTemplate(value, static string) bool {
+	if strings.ContainsAny(value, " \t\r\n\"'`<>\\") {
+		return false
+	}
+
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		host := value[strings.Index(value, "//")+2:]
+		return len(host) > 3 && !strings.HasPrefix(host, "{")
+	case strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "//"):
+		if !isValidEndpoint(value) || matchesAny(endpointNoise, value) {
+			return false
+		}
+		// Require a real path segment, so "/" + a + "/" + b is ignored
+		letters := 0
+		for _, c := range static {
+			if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+				letters++
+			}
+		}
+		return letters >= 2
+	}
+	return false
+}
+
+// codeTokens returns the tokens of the scan without comments
+func (s *scan) codeTokens() []lexer.Token {
+	s.tokenize()
+	code := make([]lexer.Token, 0, len(s.tokens))
+	for _, tok := range s.tokens {
+		if tok.Kind != lexer.Comment {
+			code = append(code, tok)
+		}
+	}
+	return code
+}
+
+// fold returns the folder of the scan's code tokens, created once per scan
+func (s *scan) fold() *folder {
+	if s.folder == nil {
+		s.folder = newFolder(s.codeTokens())
+	}
+	return s.folder
+}
+
+// extractDynamicEndpoints reports endpoints built from string
+// concatenations and template literals
+func (a *Analyzer) extractDynamicEndpoints(s *scan) {
+	f := s.fold()
+	for _, expr := range f.expressions() {
+		value, params, ok := endpointTemplate(expr.parts, true)
+		if !ok {
+			continue
+		}
+
+		start := f.tokens[expr.start].Offset
+		length := f.tokens[expr.end-1].End - start
+		if length > maxExpressionSnippet {
+			length = maxExpressionSnippet
+		}
+
+		finding := newFinding(s, dynamicEndpointRule, value, s.locate(start, length))
+		finding.Params = params
+		if len(params) > 0 {
+			finding.Confidence = ConfidenceMedium
+		}
+		a.add(s, finding)
+	}
+}
@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"math"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// Confidence levels
+const (
+	ConfidenceLow    = "low"
+	ConfidenceMedium = "medium"
+	ConfidenceHigh   = "high"
+)
+
+// EntropyThresholds holds the minimum Shannon entropy, in bits per
+// character, a value must reach to be reported as a generic secret.
+// Each character set has its own threshold since their maximum entropy
+// differs (4 bits for hex, ~5.95 for alphanumeric, 6 for base64).
+// A value of n characters cannot exceed log2(n) bits per character, so
+// for short values the threshold is lowered to stay below that maximum.
+type EntropyThresholds struct {
+	Hex    float64
+	Base64 float64
+	Alnum  float64
+}
+
+// DefaultEntropyThresholds returns thresholds tuned for keys of 32+ chars.
+// Shorter keys are held to the length-capped threshold of lengthThreshold.
+func DefaultEntropyThresholds() EntropyThresholds {
+	return EntropyThresholds{
+		Hex:    3.0,
+		Base64: 4.2,
+		Alnum:  3.7,
+	}
+}
+
+// lengthEntropyMargin is how far below the maximum entropy for its length
+// a value may fall and still reach the threshold. A random 16 character
+// base64 key repeats a character or two and scores about 3.75 of 4 bits.
+const lengthEntropyMargin = 0.5
+
+// Character sets recognised by the generic secret detector
+const (
+	charsetHex    = "hex"
+	charsetAlnum  = "alnum"
+	charsetBase64 = "base64"
+)
+
+// genericSecretPattern matches string literals assigned to identifiers that
+// suggest a credential, e.g. apiKey: "..." or client_secret = '...'
+var genericSecretPattern = regexp.MustCompile(`(?i)[a-z0-9_$.-]*(?:api[_-]?key|secret|token|passw(?:or)?d|pwd|auth|credential|private[_-]?key|access[_-]?key)[a-z0-9_$-]*["']?\s*[:=]\s*["'\x60]([^"'\x60\s]{16,})["'\x60]`)
+
+// GenericSecretRules builds the entropy-based generic secret rule set.
+// Its findings carry a confidence level derived from how far the value's
+// entropy exceeds the threshold for its character set.
+func GenericSecretRules(thresholds EntropyThresholds) *RuleSet {
+	return &RuleSet{
+		Name:   "generic-secrets",
+		Search: SearchSource,
+		Rules: []*Rule{
+			{
+				ID:       "generic-secret",
+				Category: types.CategorySecrets,
+				Name:     "Generic Secret",
+				Severity: SeverityMedium,
+				Pattern:  genericSecretPattern,
+				Group:    1,
+				Validate: func(value string) bool {
+					return isValidSecret(value) && entropyConfidence(value, thresholds) != ""
+				},
+				Confidence: func(value string) string {
+					return entropyConfidence(value, thresholds)
+				},
+				Tags: []string{"entropy"},
+				Examples: RuleExamples{
+					Positive: []string{
+						`const config = {apiKey: "f3a9c2e17b4d08a6e5f1c9b2d7a40e63"}`,
+						`client_secret='Zq8vR2mXw5Tn1Lp7Kd4Hs9Jb3Fc6Yg0A'`,
+						`apiKey: "Zq8+R2mX/5Tn1Lp7"`,
+					},
+					Negative: []string{
+						`apiKey: "aaaaaaaaaaaaaaaaaaaaaaaa"`,
+						`tokenType: "Bearer"`,
+						`authUrl: "https://login.corp.io/authorize"`,
+					},
+				},
+			},
+		},
+	}
+}
+
+// entropyConfidence returns the confidence that value is a secret, or ""
+// if its entropy is below the threshold for its character set
+func entropyConfidence(value string, thresholds EntropyThresholds) string {
+	var threshold float64
+	switch charset(value) {
+	case charsetHex:
+		threshold = thresholds.Hex
+	case charsetAlnum:
+		threshold = thresholds.Alnum
+	case charsetBase64:
+		threshold = thresholds.Base64
+	default:
+		return ""
+	}
+	threshold = lengthThreshold(threshold, utf8.RuneCountInString(value))
+
+	entropy := shannonEntropy(value)
+	switch {
+	case entropy < threshold:
+		return ""
+	case entropy >= threshold+0.5 && len(value) >= 24:
+		return ConfidenceHigh
+	case entropy >= threshold+0.25:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+// lengthThreshold caps threshold for a value of n characters, whose
+// entropy is at most log2(n) bits per character
+func lengthThreshold(threshold float64, n int) float64 {
+	if n < 2 {
+		return threshold
+	}
+	return math.Min(threshold, math.Log2(float64(n))-lengthEntropyMargin)
+}
+
+// charset classifies value into the smallest matching character set
+func charset(value string) string {
+	hex, alnum := true, true
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		case c >= 'g' && c <= 'z', c >= 'G' && c <= 'Z':
+			hex = false
+		case c == '+' || c == '/' || c == '=' || c == '-' || c == '_':
+			hex, alnum = false, false
+		default:
+			return ""
+		}
+	}
+
+	switch {
+	case hex:
+		return charsetHex
+	case alnum:
+		return charsetAlnum
+	default:
+		return charsetBase64
+	}
+}
+
+// shannonEntropy returns the Shannon entropy of value in bits per character
+func shannonEntropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	total := 0
+	for _, c := range value {
+		counts[c]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,528 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/0xhkx0/jsmap/pkg/lexer"
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// requestRule describes endpoints found as the URL of an HTTP client call
+var requestRule = &Rule{
+	ID:       "endpoint-request",
+	Category: types.CategoryEndpoints,
+	Name:     "HTTP Request",
+	Severity: SeverityInfo,
+	Tags:     []string{"api", "request"},
+}
+
+// httpMethods are the request methods recognised as client method names
+// and XMLHttpRequest.open arguments
+var httpMethods = map[string]bool{
+	"get":     true,
+	"post":    true,
+	"put":     true,
+	"patch":   true,
+	"delete":  true,
+	"head":    true,
+	"options": true,
+}
+
+// bodyMethods are the client methods taking the body before the config,
+// e.g. axios.post(url, data, config)
+var bodyMethods = map[string]bool{
+	"post":  true,
+	"put":   true,
+	"patch": true,
+}
+
+// httpClients are receivers whose get, post, ... methods send a request
+// with a (url, [body], config) signature: axios, Angular's HttpClient and
+// AngularJS's $http
+var httpClients = map[string]bool{
+	"axios":      true,
+	"http":       true,
+	"httpClient": true,
+	"_http":      true,
+	"$http":      true,
+}
+
+// chainedClients are receivers whose requests are configured by chained
+// calls, e.g. superagent.post(url).query({...}).set("X-Token", t).send({...})
+var chainedClients = map[string]bool{
+	"superagent": true,
+	"request":    true,
+	"agent":      true,
+}
+
+// jqueryNames are the names jQuery is called by
+var jqueryNames = map[string]bool{
+	"$":      true,
+	"jQuery": true,
+}
+
+// globalNames are receivers of the global fetch
+var globalNames = map[string]bool{
+	"window":     true,
+	"self":       true,
+	"globalThis": true,
+}
+
+// maxXHRLookahead bounds how many tokens after XMLHttpRequest.open are
+// searched for the setRequestHeader and send calls of the same request
+const maxXHRLookahead = 400
+
+// callSite is an HTTP request made in the code: the token its URL argument
+// starts at, the client making it and what is known about the request
+type callSite struct {
+	url     int
+	client  string
+	request types.Request
+}
+
+// addMethod records a request method, ignoring values that are not one
+func (c *callSite) addMethod(method string) {
+	if httpMethods[strings.ToLower(method)] {
+		c.request.Methods = append(c.request.Methods, strings.ToUpper(method))
+	}
+}
+
+// object is an object literal: its keys in order and the token each value
+// starts at
+type object struct {
+	keys   []string
+	values map[string]int
+}
+
+// add records a key of the object
+func (o *object) add(key string, value int) {
+	if o.values == nil {
+		o.values = make(map[string]int)
+	}
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// has reports whether the object has the key
+func (o object) has(key string) bool {
+	_, ok := o.values[key]
+	return ok
+}
+
+// call returns the names of the member chain called at token i, e.g.
+// [this http get] for this.http.get(...), and the index of its "("
+func (f *folder) call(i int) ([]string, int, bool) {
+	tok := f.tokens[i]
+	if tok.Kind != lexer.Identifier && !tok.Is("this") {
+		return nil, 0, false
+	}
+	if i > 0 && (f.tokens[i-1].Is(".") || f.tokens[i-1].Is("?.")) {
+		return nil, 0, false
+	}
+
+	names := []string{tok.Raw}
+	j := i + 1
+	for j+1 < len(f.tokens) && (f.tokens[j].Is(".") || f.tokens[j].Is("?.")) &&
+		(f.tokens[j+1].Kind == lexer.Identifier || f.tokens[j+1].Kind == lexer.Keyword) {
+		names = append(names, f.tokens[j+1].Raw)
+		j += 2
+	}
+	if j >= len(f.tokens) || !f.tokens[j].Is("(") {
+		return nil, 0, false
+	}
+	return names, j, true
+}
+
+// arguments returns the token index each argument of the call opened at
+// token open starts at, and the index of the closing ")"
+func (f *folder) arguments(open int) ([]int, int) {
+	end := f.closing(open)
+	var args []int
+	for j := open + 1; j < end; j = f.next(j, end) + 1 {
+		args = append(args, j)
+	}
+	return args, end
+}
+
+// next returns the index of the "," ending the list item at token i, or
+// end if it is the last item before the closing bracket at end
+func (f *folder) next(i, end int) int {
+	depth := 0
+	for j := i; j < end; j++ {
+		switch {
+		case f.tokens[j].Is("("), f.tokens[j].Is("["), f.tokens[j].Is("{"):
+			depth++
+		case f.tokens[j].Is(")"), f.tokens[j].Is("]"), f.tokens[j].Is("}"):
+			depth--
+		case depth == 0 && f.tokens[j].Is(","):
+			return j
+		}
+	}
+	return end
+}
+
+// argument returns the token argument n of args starts at, or -1
+func argument(args []int, n int) int {
+	if n < len(args) {
+		return args[n]
+	}
+	return -1
+}
+
+// stringAt returns the constant string of the expression at token i
+func (f *folder) stringAt(i int) (string, bool) {
+	if i < 0 {
+		return "", false
+	}
+	expr, ok := f.parse(i)
+	if !ok || !f.endsStatement(expr.end) {
+		return "", false
+	}
+	return expr.constant()
+}
+
+// object returns the object at token i: an object literal, a name assigned
+// one, JSON.stringify of one, or a new Headers/HttpHeaders/HttpParams built
+// from one or with chained .set() and .append() calls
+func (f *folder) object(i int) (object, bool) {
+	if i < 0 || i >= len(f.tokens) {
+		return object{}, false
+	}
+
+	tok := f.tokens[i]
+	if tok.Kind == lexer.Identifier && f.endsStatement(i+1) {
+		if rhs, ok := f.objects[tok.Raw]; ok && rhs >= 0 {
+			return f.literal(rhs), true
+		}
+		return object{}, false
+	}
+
+	switch {
+	case tok.Is("{"):
+		return f.literal(i), true
+	case tok.Is("JSON") && i+3 < len(f.tokens) && f.tokens[i+1].Is(".") &&
+		f.tokens[i+2].Is("stringify") && f.tokens[i+3].Is("("):
+		return f.object(i + 4)
+	case tok.Is("new") && i+2 < len(f.tokens) && f.tokens[i+1].Kind == lexer.Identifier && f.tokens[i+2].Is("("):
+		args, end := f.arguments(i + 2)
+		obj, _ := f.object(argument(args, 0))
+		// new HttpParams({fromObject: {...}})
+		if value, ok := obj.values["fromObject"]; ok {
+			obj, _ = f.object(value)
+		}
+		for end+3 < len(f.tokens) && f.tokens[end+1].Is(".") &&
+			(f.tokens[end+2].Is("set") || f.tokens[end+2].Is("append")) && f.tokens[end+3].Is("(") {
+			args, end = f.arguments(end + 3)
+			if key, ok := f.stringAt(argument(args, 0)); ok {
+				obj.add(key, argument(args, 1))
+			}
+		}
+		return obj, true
+	}
+	return object{}, false
+}
+
+// literal returns the keys of the object literal opened at token open.
+// Shorthand properties are kept with the property name as their value;
+// spreads, computed keys and methods are skipped.
+func (f *folder) literal(open int) object {
+	var obj object
+	end := f.closing(open)
+	for j := open + 1; j < end; j = f.next(j, end) + 1 {
+		tok, next := f.tokens[j], f.tokens[j+1]
+		switch {
+		case (tok.Kind == lexer.Identifier || tok.Kind == lexer.Keyword || tok.Kind == lexer.String ||
+			tok.Kind == lexer.Number) && next.Is(":"):
+			obj.add(tok.Value, j+2)
+		case tok.Kind == lexer.Identifier && (next.Is(",") || j+1 == end):
+			obj.add(tok.Raw, j)
+		}
+	}
+	return obj
+}
+
+// keys returns the keys of the object at token i
+func (f *folder) keys(i int) []string {
+	obj, _ := f.object(i)
+	return obj.keys
+}
+
+// configure reads the method, query parameters, headers and body of a
+// request from a fetch, axios, jQuery or Angular config object
+func (f *folder) configure(site *callSite, config object) {
+	for _, key := range []string{"method", "type"} {
+		if !config.has(key) {
+			continue
+		}
+		if method, ok := f.stringAt(config.values[key]); ok {
+			site.addMethod(method)
+		}
+	}
+	if config.has("params") {
+		site.request.Query = append(site.request.Query, f.keys(config.values["params"])...)
+	}
+	if config.has("headers") {
+		site.request.Headers = append(site.request.Headers, f.keys(config.values["headers"])...)
+	}
+	for _, key := range []string{"body", "data"} {
+		if config.has(key) {
+			site.request.Body = append(site.request.Body, f.keys(config.values[key])...)
+		}
+	}
+}
+
+// request returns the HTTP request made by the call at token i
+func (f *folder) request(i int) (callSite, bool) {
+	names, open, ok := f.call(i)
+	if !ok {
+		return callSite{}, false
+	}
+	args, end := f.arguments(open)
+	if len(args) == 0 {
+		return callSite{}, false
+	}
+
+	method := names[len(names)-1]
+	receiver := ""
+	if len(names) > 1 {
+		receiver = names[len(names)-2]
+	}
+
+	site := callSite{url: args[0]}
+	switch {
+	case method == "fetch" && (len(names) == 1 || len(names) == 2 && globalNames[receiver]):
+		site.client = "fetch"
+		if config, ok := f.object(argument(args, 1)); ok {
+			f.configure(&site, config)
+		}
+
+	case method == "ajax" && jqueryNames[receiver]:
+		site.client = "jquery"
+		config, ok := f.object(args[0])
+		if ok && f.tokens[args[0]].Is("{") {
+			if !config.has("url") {
+				return callSite{}, false
+			}
+			site.url = config.values["url"]
+		} else {
+			config, _ = f.object(argument(args, 1))
+		}
+		f.configure(&site, config)
+		// jQuery sends the data of GET requests as the query string
+		if len(site.request.Methods) == 0 || site.request.Methods[0] == "GET" {
+			site.request.Query = append(site.request.Query, site.request.Body...)
+			site.request.Body = nil
+		}
+
+	case (method == "get" || method == "post" || method == "getJSON") && jqueryNames[receiver]:
+		site.client = "jquery"
+		data := f.keys(argument(args, 1))
+		if method == "post" {
+			site.addMethod("POST")
+			site.request.Body = data
+		} else {
+			site.addMethod("GET")
+			site.request.Query = data
+		}
+
+	case method == "axios" && len(names) == 1, method == "request" && receiver == "axios":
+		site.client = "axios"
+		config, ok := f.object(args[0])
+		if !ok || !config.has("url") {
+			return callSite{}, false
+		}
+		site.url = config.values["url"]
+		f.configure(&site, config)
+
+	case method == "open" && receiver != "" && len(args) >= 2:
+		verb, ok := f.stringAt(args[0])
+		if !ok || !httpMethods[strings.ToLower(verb)] {
+			return callSite{}, false
+		}
+		site.client = "xhr"
+		site.url = args[1]
+		site.addMethod(verb)
+		f.xhr(&site, strings.Join(names[:len(names)-1], "."), end)
+
+	case method == "request" && httpClients[receiver] && len(args) >= 2:
+		// Angular's http.request("POST", url, {body, params, headers})
+		verb, ok := f.stringAt(args[0])
+		if !ok || !httpMethods[strings.ToLower(verb)] {
+			return callSite{}, false
+		}
+		site.client = clientName(receiver)
+		site.url = args[1]
+		site.addMethod(verb)
+		if config, ok := f.object(argument(args, 2)); ok {
+			f.configure(&site, config)
+		}
+
+	case httpMethods[strings.ToLower(method)] && httpClients[receiver]:
+		site.client = clientName(receiver)
+		site.addMethod(method)
+		configArg := 1
+		if bodyMethods[strings.ToLower(method)] {
+			site.request.Body = f.keys(argument(args, 1))
+			configArg = 2
+		}
+		if config, ok := f.object(argument(args, configArg)); ok {
+			f.configure(&site, config)
+		}
+
+	case httpMethods[strings.ToLower(method)] && chainedClients[receiver]:
+		site.client = "superagent"
+		site.addMethod(method)
+		f.chain(&site, end)
+
+	default:
+		return callSite{}, false
+	}
+
+	if site.url < 0 {
+		return callSite{}, false
+	}
+	if len(site.request.Methods) == 0 {
+		site.addMethod("GET")
+	}
+	return site, true
+}
+
+// clientName names the client of a receiver for the request's tags
+func clientName(receiver string) string {
+	switch receiver {
+	case "axios":
+		return "axios"
+	case "$http":
+		return "angularjs"
+	}
+	return "angular"
+}
+
+// xhr completes an XMLHttpRequest opened on receiver with the headers and
+// body of the setRequestHeader and send calls following the open call,
+// up to the next open on the same receiver
+func (f *folder) xhr(site *callSite, receiver string, from int) {
+	limit := from + maxXHRLookahead
+	if limit > len(f.tokens) {
+		limit = len(f.tokens)
+	}
+	for j := from + 1; j < limit; j++ {
+		names, open, ok := f.call(j)
+		if !ok || strings.Join(names[:len(names)-1], ".") != receiver {
+			continue
+		}
+		args, _ := f.arguments(open)
+		switch names[len(names)-1] {
+		case "open":
+			return
+		case "setRequestHeader":
+			if header, ok := f.stringAt(argument(args, 0)); ok {
+				site.request.Headers = append(site.request.Headers, header)
+			}
+		case "send":
+			site.request.Body = append(site.request.Body, f.keys(argument(args, 0))...)
+			return
+		}
+	}
+}
+
+// chain completes a superagent style request with the .query(), .set() and
+// .send() calls chained after the call closed at token end
+func (f *folder) chain(site *callSite, end int) {
+	for end+3 < len(f.tokens) && f.tokens[end+1].Is(".") && f.tokens[end+3].Is("(") {
+		method := f.tokens[end+2].Raw
+		var args []int
+		args, end = f.arguments(end + 3)
+		switch method {
+		case "query":
+			site.request.Query = append(site.request.Query, f.keys(argument(args, 0))...)
+		case "set":
+			if header, ok := f.stringAt(argument(args, 0)); ok {
+				site.request.Headers = append(site.request.Headers, header)
+			} else {
+				site.request.Headers = append(site.request.Headers, f.keys(argument(args, 0))...)
+			}
+		case "send":
+			site.request.Body = append(site.request.Body, f.keys(argument(args, 0))...)
+		}
+	}
+}
+
+// queryParams returns the names of the query parameters in a URL's parts,
+// e.g. q and page for "/search?q=" + q + "&page=" + page
+func queryParams(parts []part) []string {
+	var b strings.Builder
+	for _, p := range parts {
+		if p.param != "" {
+			b.WriteString("{}")
+			continue
+		}
+		b.WriteString(p.text)
+	}
+
+	url := b.String()
+	start := strings.IndexByte(url, '?')
+	if start < 0 {
+		return nil
+	}
+	query := url[start+1:]
+	if end := strings.IndexByte(query, '#'); end >= 0 {
+		query = query[:end]
+	}
+
+	var names []string
+	for _, pair := range strings.Split(query, "&") {
+		name := pair
+		if eq := strings.IndexByte(pair, '='); eq >= 0 {
+			name = pair[:eq]
+		}
+		if name != "" && !strings.Contains(name, "{}") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// extractRequests reports the endpoints requested through fetch,
+// XMLHttpRequest, jQuery, axios, Angular and superagent, along with the
+// method, query parameters, headers and body keys of each request
+func (a *Analyzer) extractRequests(s *scan) {
+	f := s.fold()
+	for i := range f.tokens {
+		site, ok := f.request(i)
+		if !ok {
+			continue
+		}
+		expr, ok := f.parse(site.url)
+		if !ok || !f.endsStatement(expr.end) {
+			continue
+		}
+		value, params, ok := endpointTemplate(expr.parts, false)
+		if !ok {
+			continue
+		}
+
+		request := &types.Request{}
+		request.Merge(&site.request)
+		request.Merge(&types.Request{Query: queryParams(expr.parts)})
+
+		start := f.tokens[expr.start].Offset
+		length := f.tokens[expr.end-1].End - start
+		if length > maxExpressionSnippet {
+			length = maxExpressionSnippet
+		}
+
+		finding := newFinding(s, requestRule, value, s.locate(start, length))
+		finding.Params = params
+		finding.Request = request
+		finding.Tags = append(append([]string(nil), requestRule.Tags...), site.client)
+		if len(params) > 0 {
+			finding.Confidence = ConfidenceMedium
+		}
+		// The URL was usually counted already as a literal or dynamic
+		// endpoint, the call site only adds how it is requested
+		a.merge(s, finding)
+	}
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// endpoint returns the endpoint finding with the given value
+func endpoint(t *testing.T, findings *types.Findings, value string) types.Finding {
+	t.Helper()
+	for _, finding := range findings.ByCategory(types.CategoryEndpoints) {
+		if finding.Value == value {
+			return finding
+		}
+	}
+	t.Fatalf("endpoint %q not found", value)
+	return types.Finding{}
+}
+
+func TestRequestCallSiteDoesNotCountOccurrence(t *testing.T) {
+	a := NewAnalyzer(false)
+
+	called := endpoint(t, a.Analyze(`fetch("/api/v1/cart", {method: "POST", headers: {"X-Token": t}});`, "app.js"), "/api/v1/cart")
+	bare := endpoint(t, a.Analyze(`const cart = "/api/v1/cart";`, "app.js"), "/api/v1/cart")
+
+	if called.Occurrences != bare.Occurrences {
+		t.Errorf("call site counted %d occurrences, bare literal %d", called.Occurrences, bare.Occurrences)
+	}
+	if called.Request == nil || len(called.Request.Methods) != 1 || called.Request.Methods[0] != "POST" {
+		t.Errorf("request not merged: %+v", called.Request)
+	}
+	if len(called.Request.Headers) != 1 || called.Request.Headers[0] != "X-Token" {
+		t.Errorf("headers not merged: %+v", called.Request.Headers)
+	}
+	if !hasTag(called.Tags, "fetch") {
+		t.Errorf("client tag not merged: %v", called.Tags)
+	}
+	for _, rule := range EndpointRules.Rules {
+		if hasTag(rule.Tags, "fetch") {
+			t.Errorf("%s: merging tags modified the rule's tags", rule.ID)
+		}
+	}
+}
+
+// hasTag reports whether tags contains tag
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
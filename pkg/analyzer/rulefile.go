@@ -0,0 +1,264 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// RuleFile is the top-level document of a YAML or JSON rule file
+type RuleFile struct {
+	Rules []RuleDefinition `json:"rules" yaml:"rules"`
+}
+
+// RuleDefinition describes a custom rule as written in a rule file
+//
+//	rules:
+//	  - id: acme-api-token
+//	    category: secrets
+//	    name: ACME API Token
+//	    regex: 'acme_(?:live|test)_[a-z0-9]{32}'
+//	    severity: high
+//	    deny: ['_test_']
+//	    examples:
+//	      positive: ['token = "acme_live_0123456789abcdef0123456789abcdef"']
+//	      negative: ['token = "acme_test_0123456789abcdef0123456789abcdef"']
+type RuleDefinition struct {
+	ID       string `json:"id" yaml:"id"`
+	Category string `json:"category" yaml:"category"`
+	Name     string `json:"name" yaml:"name"`
+	Regex    string `json:"regex" yaml:"regex"`
+	// Group is the capture group holding the value (0 = whole match)
+	Group    int    `json:"group" yaml:"group"`
+	Severity string `json:"severity" yaml:"severity"`
+	// Allow, when set, requires values to match at least one of these patterns
+	Allow []string `json:"allow" yaml:"allow"`
+	// Deny lists patterns for values that are never reported
+	Deny     []string     `json:"deny" yaml:"deny"`
+	Tags     []string     `json:"tags" yaml:"tags"`
+	Examples RuleExamples `json:"examples" yaml:"examples"`
+}
+
+// ruleSearch is the search mode used for each category
+var ruleSearch = map[string]int{
+	types.CategoryEndpoints: SearchWholeLiterals,
+	types.CategoryURLs:      SearchLiterals,
+	types.CategorySecrets:   SearchSource,
+	types.CategoryEmails:    SearchSource,
+	types.CategoryFiles:     SearchWholeLiterals,
+}
+
+// categoryValidators is the built-in validator for each category
+var categoryValidators = map[string]func(string) bool{
+	types.CategoryEndpoints: isValidEndpoint,
+	types.CategoryURLs:      isValidURL,
+	types.CategorySecrets:   isValidSecret,
+	types.CategoryEmails:    isValidEmail,
+	types.CategoryFiles:     isValidFile,
+}
+
+// severities lists the accepted severity levels
+var severities = map[string]bool{
+	SeverityInfo:     true,
+	SeverityLow:      true,
+	SeverityMedium:   true,
+	SeverityHigh:     true,
+	SeverityCritical: true,
+}
+
+// Compile validates the definition and builds a Rule from it
+func (d *RuleDefinition) Compile() (*Rule, error) {
+	if d.ID == "" {
+		return nil, fmt.Errorf("rule is missing an id")
+	}
+	if _, ok := ruleSearch[d.Category]; !ok {
+		return nil, fmt.Errorf("%s: unknown category %q", d.ID, d.Category)
+	}
+
+	severity := strings.ToLower(d.Severity)
+	if severity == "" {
+		severity = SeverityInfo
+		if d.Category == types.CategorySecrets {
+			severity = SeverityHigh
+		}
+	}
+	if !severities[severity] {
+		return nil, fmt.Errorf("%s: unknown severity %q", d.ID, d.Severity)
+	}
+
+	if d.Regex == "" {
+		return nil, fmt.Errorf("%s: rule is missing a regex", d.ID)
+	}
+	pattern, err := regexp.Compile(d.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid regex: %v", d.ID, err)
+	}
+	if d.Group < 0 || d.Group > pattern.NumSubexp() {
+		return nil, fmt.Errorf("%s: capture group %d out of range", d.ID, d.Group)
+	}
+
+	allow, err := compileList(d.ID, "allow", d.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := compileList(d.ID, "deny", d.Deny)
+	if err != nil {
+		return nil, err
+	}
+
+	name := d.Name
+	if name == "" {
+		name = d.ID
+	}
+
+	// Custom rules keep the built-in validation for their category so they
+	// inherit the same noise handling as the rules they extend
+	return &Rule{
+		ID:       d.ID,
+		Category: d.Category,
+		Name:     name,
+		Severity: severity,
+		Pattern:  pattern,
+		Group:    d.Group,
+		Validate: categoryValidators[d.Category],
+		Noise:    deny,
+		Allow:    allow,
+		Tags:     d.Tags,
+		Examples: d.Examples,
+	}, nil
+}
+
+// compileList compiles the patterns of an allow or deny list
+func compileList(id, list string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid %s pattern %q: %v", id, list, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// LoadRules loads custom rules from a rule file or from every .yaml, .yml
+// and .json file in a directory
+func LoadRules(path string) ([]*Rule, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	var rules []*Rule
+	seenIDs := make(map[string]string)
+	for _, file := range files {
+		fileRules, err := loadRuleFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range fileRules {
+			if previous, exists := seenIDs[rule.ID]; exists {
+				return nil, fmt.Errorf("%s: duplicate rule id %q (first defined in %s)", file, rule.ID, previous)
+			}
+			seenIDs[rule.ID] = file
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// loadRuleFile parses and compiles a single rule file
+func loadRuleFile(file string) ([]*Rule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var ruleFile RuleFile
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		err = json.Unmarshal(data, &ruleFile)
+	} else {
+		err = yaml.Unmarshal(data, &ruleFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	rules := make([]*Rule, 0, len(ruleFile.Rules))
+	for i := range ruleFile.Rules {
+		rule, err := ruleFile.Rules[i].Compile()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// MergeRuleSets returns copies of ruleSets with custom rules added to the
+// set of their category. A custom rule replaces a rule with the same ID.
+// The given rule sets are not modified.
+func MergeRuleSets(ruleSets []*RuleSet, custom []*Rule) []*RuleSet {
+	merged := make([]*RuleSet, 0, len(ruleSets))
+	byName := make(map[string]*RuleSet)
+	for _, ruleSet := range ruleSets {
+		clone := &RuleSet{
+			Name:   ruleSet.Name,
+			Search: ruleSet.Search,
+			Rules:  append([]*Rule(nil), ruleSet.Rules...),
+		}
+		merged = append(merged, clone)
+		byName[clone.Name] = clone
+	}
+
+	for _, rule := range custom {
+		// Drop any rule this one overrides
+		for _, ruleSet := range merged {
+			for i, existing := range ruleSet.Rules {
+				if existing.ID == rule.ID {
+					ruleSet.Rules = append(ruleSet.Rules[:i], ruleSet.Rules[i+1:]...)
+					break
+				}
+			}
+		}
+
+		ruleSet, exists := byName[rule.Category]
+		if !exists {
+			ruleSet = &RuleSet{Name: rule.Category, Search: ruleSearch[rule.Category]}
+			merged = append(merged, ruleSet)
+			byName[rule.Category] = ruleSet
+		}
+		ruleSet.Rules = append(ruleSet.Rules, rule)
+	}
+
+	return merged
+}
@@ -0,0 +1,152 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRuleFile writes a rule file into dir
+func writeRuleFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		def  RuleDefinition
+		want string
+	}{
+		{"missing id", RuleDefinition{Category: "secrets", Regex: `x`}, "missing an id"},
+		{"bad regex", RuleDefinition{ID: "r", Category: "secrets", Regex: `acme_(`}, "invalid regex"},
+		{"group out of range", RuleDefinition{ID: "r", Category: "secrets", Regex: `acme_(\w+)`, Group: 2}, "capture group 2 out of range"},
+		{"negative group", RuleDefinition{ID: "r", Category: "secrets", Regex: `acme`, Group: -1}, "capture group -1 out of range"},
+		{"unknown category", RuleDefinition{ID: "r", Category: "tokens", Regex: `x`}, `unknown category "tokens"`},
+		{"unknown severity", RuleDefinition{ID: "r", Category: "secrets", Regex: `x`, Severity: "urgent"}, `unknown severity "urgent"`},
+		{"bad deny", RuleDefinition{ID: "r", Category: "secrets", Regex: `x`, Deny: []string{`[`}}, "invalid deny pattern"},
+		{"missing regex", RuleDefinition{ID: "r", Category: "secrets"}, "missing a regex"},
+	}
+	for _, tt := range tests {
+		_, err := tt.def.Compile()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Compile() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCompileDefaults(t *testing.T) {
+	rule, err := (&RuleDefinition{ID: "acme", Category: "secrets", Regex: `acme_live_[a-z0-9]{32}`, Severity: "HIGH"}).Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Name != "acme" || rule.Severity != SeverityHigh || rule.Validate == nil {
+		t.Errorf("got name %q, severity %q, validator set %v", rule.Name, rule.Severity, rule.Validate != nil)
+	}
+
+	rule, err = (&RuleDefinition{ID: "acme-path", Category: "endpoints", Regex: `"(/acme/[a-z]+)"`, Group: 1}).Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Severity != SeverityInfo {
+		t.Errorf("endpoint default severity = %q, want %q", rule.Severity, SeverityInfo)
+	}
+}
+
+func TestLoadRulesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeRuleFile(t, dir, "a.yaml", `rules:
+  - id: acme-token
+    category: secrets
+    regex: 'acme_live_[a-z0-9]{32}'
+    examples:
+      positive: ['token = "acme_live_0123456789abcdef0123456789abcdef"']
+`)
+	writeRuleFile(t, dir, "b.json", `{"rules": [{"id": "acme-path", "category": "endpoints", "regex": "\"(/acme/[a-z]+)\"", "group": 1}]}`)
+	writeRuleFile(t, dir, "notes.txt", `not a rule file`)
+
+	rules, err := LoadRules(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].ID != "acme-token" || rules[1].ID != "acme-path" {
+		t.Fatalf("got %d rules, want acme-token and acme-path in file order", len(rules))
+	}
+	for _, rule := range rules {
+		for _, err := range rule.CheckExamples(ruleSearch[rule.Category]) {
+			t.Error(err)
+		}
+	}
+}
+
+func TestLoadRulesDuplicateIDAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	rule := `rules:
+  - id: acme-token
+    category: secrets
+    regex: 'acme_[a-z0-9]{32}'
+`
+	writeRuleFile(t, dir, "a.yaml", rule)
+	writeRuleFile(t, dir, "b.yml", rule)
+
+	_, err := LoadRules(dir)
+	if err == nil || !strings.Contains(err.Error(), `duplicate rule id "acme-token"`) || !strings.Contains(err.Error(), "a.yaml") {
+		t.Errorf("LoadRules() error = %v, want a duplicate id error naming a.yaml", err)
+	}
+}
+
+func TestLoadRulesReportsFile(t *testing.T) {
+	path := writeRuleFile(t, t.TempDir(), "bad.yaml", `rules:
+  - id: broken
+    category: secrets
+    regex: '('
+`)
+	_, err := LoadRules(path)
+	if err == nil || !strings.Contains(err.Error(), "bad.yaml") || !strings.Contains(err.Error(), "invalid regex") {
+		t.Errorf("LoadRules() error = %v, want an invalid regex error naming the file", err)
+	}
+}
+
+func TestMergeRuleSetsOverridesBuiltIn(t *testing.T) {
+	custom, err := (&RuleDefinition{
+		ID:       "github-pat",
+		Category: "secrets",
+		Regex:    `ghp_[0-9a-zA-Z]{36}`,
+		Severity: "low",
+	}).Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builtIn := DefaultRuleSets()
+	before := len(SecretRules.Rules)
+	merged := MergeRuleSets(builtIn, []*Rule{custom})
+
+	count := 0
+	for _, ruleSet := range merged {
+		for _, rule := range ruleSet.Rules {
+			if rule.ID == "github-pat" {
+				count++
+				if rule != custom {
+					t.Error("built-in github-pat rule kept")
+				}
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("github-pat defined %d times after merge, want 1", count)
+	}
+	if len(SecretRules.Rules) != before {
+		t.Error("MergeRuleSets modified the built-in rule set")
+	}
+	for _, rule := range SecretRules.Rules {
+		if rule == custom {
+			t.Error("custom rule added to the built-in rule set")
+		}
+	}
+}
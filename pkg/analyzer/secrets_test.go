@@ -0,0 +1,28 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+func TestDefaultRuleSetExamples(t *testing.T) {
+	for _, ruleSet := range DefaultRuleSets() {
+		for _, rule := range ruleSet.Rules {
+			for _, err := range rule.CheckExamples(ruleSet.Search) {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func TestSecretRulesHaveExamples(t *testing.T) {
+	for _, rule := range SecretRules.Rules {
+		if rule.Category != types.CategorySecrets {
+			t.Errorf("%s: category %q, want %q", rule.ID, rule.Category, types.CategorySecrets)
+		}
+		if len(rule.Examples.Positive) == 0 || len(rule.Examples.Negative) == 0 {
+			t.Errorf("%s: needs positive and negative examples", rule.ID)
+		}
+	}
+}
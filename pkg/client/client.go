@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,12 +21,36 @@ type Config struct {
 	ProxyURL  string
 	Headers   map[string]string
 	Verbose   bool
+	// RateLimit is the maximum number of requests Fetch sends per second,
+	// across all goroutines. Zero means no limit.
+	RateLimit float64
 }
 
-// HTTPClient wraps http.Client with custom configuration
+// HTTPClient wraps http.Client with custom configuration. Fetch caches
+// successful responses up to maxCacheSize bytes of bodies for the lifetime
+// of the client, so a script, source map or original source referenced many
+// times in a run is downloaded once.
 type HTTPClient struct {
 	Client *http.Client
 	Config *Config
+
+	mu    sync.Mutex
+	cache map[string]*cachedFetch
+	// cacheSize is the total size of the bodies kept in cache
+	cacheSize int
+	// next is the earliest time the rate limit lets the next request start
+	next time.Time
+}
+
+// maxCacheSize bounds the total size of the response bodies Fetch keeps
+const maxCacheSize = 64 << 20
+
+// cachedFetch is a response of Fetch, shared by every caller asking for the
+// same URL. done is closed once the request has finished.
+type cachedFetch struct {
+	done chan struct{}
+	resp *Response
+	err  error
 }
 
 // New creates a new HTTP client
@@ -53,18 +78,96 @@ func New(config *Config) *HTTPClient {
 	return &HTTPClient{
 		Client: httpClient,
 		Config: config,
+		cache:  make(map[string]*cachedFetch),
 	}
 }
 
+// Response is a fetched resource
+type Response struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
 // FetchURL fetches content from a URL
 func (hc *HTTPClient) FetchURL(targetURL string) (string, int, error) {
-	if hc.Config.Verbose {
-		fmt.Printf("[*] Fetching: %s\n", targetURL)
+	resp, err := hc.Fetch(targetURL)
+	if err != nil {
+		if resp != nil {
+			return "", resp.StatusCode, err
+		}
+		return "", 0, err
+	}
+	return resp.Body, resp.StatusCode, nil
+}
+
+// Fetch fetches a URL and returns its body along with the status code and
+// response headers. If reading the body fails, the response is returned with
+// the error. Concurrent calls for the same URL wait for and share a single
+// request. Later calls reuse the response while the cache has room for it;
+// failed requests are not kept, so they are retried. It is safe for
+// concurrent use.
+func (hc *HTTPClient) Fetch(targetURL string) (*Response, error) {
+	hc.mu.Lock()
+	if hc.cache == nil {
+		hc.cache = make(map[string]*cachedFetch)
+	}
+	cached, exists := hc.cache[targetURL]
+	if !exists {
+		cached = &cachedFetch{done: make(chan struct{})}
+		hc.cache[targetURL] = cached
+	}
+	hc.mu.Unlock()
+
+	if !exists {
+		cached.resp, cached.err = hc.fetch(targetURL)
+		hc.mu.Lock()
+		if cached.err != nil || hc.cacheSize+len(cached.resp.Body) > maxCacheSize {
+			delete(hc.cache, targetURL)
+		} else {
+			hc.cacheSize += len(cached.resp.Body)
+		}
+		hc.mu.Unlock()
+		close(cached.done)
+	} else {
+		<-cached.done
+		if hc.Config.Verbose {
+			fmt.Printf("[*] Cached: %s\n", targetURL)
+		}
 	}
 
+	if cached.resp == nil {
+		return nil, cached.err
+	}
+	resp := *cached.resp
+	return &resp, cached.err
+}
+
+// wait blocks until the rate limit allows another request
+func (hc *HTTPClient) wait() {
+	if hc.Config.RateLimit <= 0 {
+		return
+	}
+	interval := time.Duration(float64(time.Second) / hc.Config.RateLimit)
+
+	hc.mu.Lock()
+	now := time.Now()
+	if hc.next.Before(now) {
+		hc.next = now
+	}
+	delay := hc.next.Sub(now)
+	hc.next = hc.next.Add(interval)
+	hc.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+// fetch requests a URL, bypassing the cache
+func (hc *HTTPClient) fetch(targetURL string) (*Response, error) {
 	req, err := http.NewRequest("GET", targetURL, nil)
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
 
 	// Set headers
@@ -76,22 +179,47 @@ func (hc *HTTPClient) FetchURL(targetURL string) (string, int, error) {
 		req.Header.Set(k, v)
 	}
 
+	resp, err := hc.Do(req)
+	if resp != nil {
+		// Keep the URL as requested rather than as re-encoded by net/url
+		resp.URL = targetURL
+	}
+	return resp, err
+}
+
+// Do sends a request as is once the rate limit allows it and reads the
+// response like Fetch, but bypasses the cache since the request may carry
+// its own method, headers or body. It is safe for concurrent use.
+func (hc *HTTPClient) Do(req *http.Request) (*Response, error) {
+	hc.wait()
+	targetURL := req.URL.String()
+	if hc.Config.Verbose {
+		fmt.Printf("[*] Fetching: %s\n", targetURL)
+	}
+
 	resp, err := hc.Client.Do(req)
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	response := &Response{
+		URL:        targetURL,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", resp.StatusCode, err
+		return response, err
 	}
+	response.Body = string(body)
 
 	if hc.Config.Verbose {
 		fmt.Printf("[+] Status: %d, Size: %d bytes\n", resp.StatusCode, len(body))
 	}
 
-	return string(body), resp.StatusCode, nil
+	return response, nil
 }
 
 // ParseRawRequest parses raw HTTP request format
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchDownloadsEachURLOnce(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"version":3,"sources":[],"mappings":""}`))
+	}))
+	defer server.Close()
+
+	hc := New(&Config{UserAgent: "jsmap-test", Timeout: 5})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := hc.Fetch(server.URL + "/vendor.js.map")
+			if err != nil || resp.StatusCode != 200 {
+				t.Errorf("Fetch() = %v, %v", resp, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+}
+
+func TestFetchRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	hc := New(&Config{UserAgent: "jsmap-test", Timeout: 5, RateLimit: 20})
+	start := time.Now()
+	for _, path := range []string{"/a.js", "/b.js", "/c.js", "/d.js", "/e.js"} {
+		if _, err := hc.Fetch(server.URL + path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Five requests at 20 per second start at least 4 * 50ms apart
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("5 requests at 20/s took %v, want at least 200ms", elapsed)
+	}
+}
+
+func TestFetchRetriesFailedRequests(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// Drop the connection without a response
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	hc := New(&Config{UserAgent: "jsmap-test", Timeout: 5})
+	if _, err := hc.Fetch(server.URL + "/app.js"); err == nil {
+		t.Fatal("first Fetch succeeded, want the dropped connection to fail")
+	}
+	resp, err := hc.Fetch(server.URL + "/app.js")
+	if err != nil || resp.Body != "ok" {
+		t.Fatalf("second Fetch = %v, %v, want the retried response", resp, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
+
+func TestFetchCacheIsBounded(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	hc := New(&Config{UserAgent: "jsmap-test", Timeout: 5})
+	hc.cacheSize = maxCacheSize - 15
+
+	// The first body fits, the second does not and is fetched every time
+	for i := 0; i < 2; i++ {
+		for _, path := range []string{"/small.js", "/large.js"} {
+			if _, err := hc.Fetch(server.URL + path); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("server got %d requests, want 3", n)
+	}
+	if hc.cacheSize != maxCacheSize-5 {
+		t.Errorf("cache size = %d, want %d", hc.cacheSize, maxCacheSize-5)
+	}
+}
+
+func TestDoRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	hc := New(&Config{UserAgent: "jsmap-test", Timeout: 5, RateLimit: 20})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest("GET", server.URL+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := hc.Do(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := hc.Fetch(server.URL + "/app.js"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests sent with Do share the limit with Fetch
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("4 requests at 20/s took %v, want at least 150ms", elapsed)
+	}
+}
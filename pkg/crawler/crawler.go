@@ -2,21 +2,32 @@ package crawler
 
 import (
 	"fmt"
-	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 
+	"github.com/0xhkx0/jsmap/internal/pool"
+	"github.com/0xhkx0/jsmap/pkg/client"
 	"github.com/0xhkx0/jsmap/pkg/sourcemap"
 )
 
 // Config holds crawl configuration
 type Config struct {
-	TargetURL       string
-	HTTPClient      *http.Client
-	FetchURL        func(string) (string, int, error)
+	TargetURL string
+	// Fetch downloads pages, scripts, source maps and original sources,
+	// normally through the configured client.HTTPClient, which downloads a
+	// URL shared by several bundles once and applies the rate limit
+	Fetch           func(string) (*client.Response, error)
 	IncludeExternal bool
+	Threads         int
 	Verbose         bool
+	// FetchSources makes the crawler download the original sources a
+	// source map lists without embedding them in sourcesContent
+	FetchSources bool
+	// OnFiles, if set, is called with the files of each crawl level in
+	// queue order as soon as the level is downloaded, before the next level
+	// is crawled
+	OnFiles func(files []JavaScriptFile)
 }
 
 // JavaScriptFile represents a discovered JS file
@@ -24,23 +35,31 @@ type JavaScriptFile struct {
 	URL      string
 	FileName string
 	Content  string
+	// StatusCode is the HTTP status of the download. Original sources
+	// extracted from a source map carry the status of their bundle.
+	StatusCode int
+	// SourceMap is the source map of the file, if one was found, for
+	// reporting findings at their original position
+	SourceMap *sourcemap.SourceMap
+	// SourceMapAttempts are the downloads of candidate source maps
+	SourceMapAttempts []sourcemap.Attempt
 }
 
 // CrawlForJavaScript discovers and downloads all JS files from a URL
 func CrawlForJavaScript(config *Config) ([]JavaScriptFile, error) {
 	var jsFiles []JavaScriptFile
 	seenURLs := make(map[string]bool)
-	queue := []string{}
 
 	if config.Verbose {
 		fmt.Printf("[*] Crawling: %s\n", config.TargetURL)
 	}
 
 	// Fetch the HTML page
-	htmlContent, statusCode, err := config.FetchURL(config.TargetURL)
+	resp, err := config.Fetch(config.TargetURL)
 	if err != nil {
 		return nil, err
 	}
+	htmlContent, statusCode := resp.Body, resp.StatusCode
 
 	if config.Verbose {
 		fmt.Printf("[*] Page status: %d, size: %d bytes\n", statusCode, len(htmlContent))
@@ -48,7 +67,6 @@ func CrawlForJavaScript(config *Config) ([]JavaScriptFile, error) {
 
 	// Extract script sources from HTML
 	jsURLs := extractJavaScriptSources(htmlContent, config.TargetURL)
-	queue = append(queue, jsURLs...)
 
 	if config.Verbose {
 		fmt.Printf("[*] Found %d JavaScript files in HTML\n", len(jsURLs))
@@ -56,78 +74,167 @@ func CrawlForJavaScript(config *Config) ([]JavaScriptFile, error) {
 
 	baseURLObj, _ := url.Parse(config.TargetURL)
 
-	// Process queue (BFS for JS files)
+	var queue []string
+	for _, jsURL := range jsURLs {
+		if !seenURLs[jsURL] {
+			seenURLs[jsURL] = true
+			queue = append(queue, jsURL)
+		}
+	}
+
+	// Process queue (BFS for JS files). Each level is downloaded by a
+	// bounded worker pool and merged back in queue order, so the result
+	// does not depend on which worker finished first.
 	for len(queue) > 0 {
-		jsURL := queue[0]
-		queue = queue[1:]
+		results := fetchAll(config, queue, baseURLObj)
 
-		// Skip if already processed
-		if seenURLs[jsURL] {
-			continue
+		var next []string
+		var level []JavaScriptFile
+		for _, result := range results {
+			level = append(level, result.files...)
+
+			for _, newURL := range result.newURLs {
+				if !seenURLs[newURL] {
+					seenURLs[newURL] = true
+					next = append(next, newURL)
+				}
+			}
 		}
-		seenURLs[jsURL] = true
+		jsFiles = append(jsFiles, level...)
+		if config.OnFiles != nil && len(level) > 0 {
+			config.OnFiles(level)
+		}
+		queue = next
+	}
+
+	if config.Verbose {
+		fmt.Printf("[*] Total JavaScript files discovered: %d\n", len(jsFiles))
+	}
+
+	return jsFiles, nil
+}
+
+// crawlResult holds everything learned from downloading a single JS file
+type crawlResult struct {
+	files   []JavaScriptFile
+	newURLs []string
+}
 
+// fetchAll downloads jsURLs using at most config.Threads workers and returns
+// the results in the same order as jsURLs
+func fetchAll(config *Config, jsURLs []string, baseURLObj *url.URL) []crawlResult {
+	results := make([]crawlResult, len(jsURLs))
+	pool.Run(len(jsURLs), config.Threads, func(i int) {
+		results[i] = fetchJavaScript(config, jsURLs[i], baseURLObj)
+	})
+	return results
+}
+
+// fetchJavaScript downloads a JS file, its source map and the JS files it references
+func fetchJavaScript(config *Config, jsURL string, baseURLObj *url.URL) crawlResult {
+	var result crawlResult
+
+	if config.Verbose {
+		fmt.Printf("[*] Fetching JS: %s\n", jsURL)
+	}
+
+	resp, err := config.Fetch(jsURL)
+	if err != nil {
 		if config.Verbose {
-			fmt.Printf("[*] Fetching JS: %s\n", jsURL)
+			fmt.Printf("[!] Error fetching %s: %v\n", jsURL, err)
 		}
+		return result
+	}
+	// Error pages are HTML, not the script, and are not analyzed
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if config.Verbose {
+			fmt.Printf("[!] Skipping %s: status %d\n", jsURL, resp.StatusCode)
+		}
+		return result
+	}
+	content := resp.Body
 
-		content, _, err := config.FetchURL(jsURL)
-		if err != nil {
-			if config.Verbose {
-				fmt.Printf("[!] Error fetching %s: %v\n", jsURL, err)
+	// Extract filename
+	fileName := extractFileName(jsURL)
+
+	file := JavaScriptFile{
+		URL:        jsURL,
+		FileName:   fileName,
+		Content:    content,
+		StatusCode: resp.StatusCode,
+	}
+
+	if config.Verbose {
+		fmt.Printf("[+] Downloaded: %s (%d bytes)\n", fileName, len(content))
+	}
+
+	// Try to fetch source map for better analysis, declared by the response
+	// headers or a sourceMappingURL comment or found by its conventional name
+	sourceMap, attempts, err := sourcemap.FetchSourceMap(jsURL, content, resp.Header, config.Fetch, config.Verbose)
+	file.SourceMapAttempts = attempts
+	if err == nil && sourceMap != nil {
+		file.SourceMap = sourceMap
+		if config.FetchSources {
+			fetchMissingSources(config, sourceMap)
+		}
+	}
+	result.files = append(result.files, file)
+
+	// Add each original source embedded in the map as an additional file
+	// named by its real path
+	if file.SourceMap != nil {
+		extracted := 0
+		for i := range sourceMap.Sources {
+			originalSource := sourceMap.SourceContent(i)
+			if originalSource == "" {
+				continue
 			}
-			continue
+			sourceURL := sourceMap.SourceURL(i)
+			result.files = append(result.files, JavaScriptFile{
+				URL:        sourceURL,
+				FileName:   sourceURL,
+				Content:    originalSource,
+				StatusCode: file.StatusCode,
+			})
+			extracted++
 		}
+		if config.Verbose && extracted > 0 {
+			fmt.Printf("[+] Extracted %d original sources from source map found via %s\n", extracted, sourceMap.Discovery)
+		}
+	}
 
-		// Extract filename
-		fileName := extractFileName(jsURL)
+	// RECURSIVE: Extract more JS files from this JS file
+	result.newURLs = extractJSFromJSContent(content, jsURL, baseURLObj)
+	if len(result.newURLs) > 0 && config.Verbose {
+		fmt.Printf("[*] Found %d additional JS files referenced in %s\n", len(result.newURLs), fileName)
+	}
 
-		jsFiles = append(jsFiles, JavaScriptFile{
-			URL:      jsURL,
-			FileName: fileName,
-			Content:  content,
-		})
+	return result
+}
 
-		if config.Verbose {
-			fmt.Printf("[+] Downloaded: %s (%d bytes)\n", fileName, len(content))
+// fetchMissingSources downloads the sources a map does not embed, resolved
+// against its sourceRoot and URL. Sources without an http(s) URL, such as
+// webpack:// paths, cannot be fetched and are skipped.
+func fetchMissingSources(config *Config, sourceMap *sourcemap.SourceMap) {
+	for i := range sourceMap.Sources {
+		if sourceMap.SourceContent(i) != "" {
+			continue
 		}
 
-		// Try to fetch source map for better analysis
-		sourceMap, err := sourcemap.FetchSourceMap(jsURL, config.HTTPClient, config.Verbose)
-		if err == nil && sourceMap != nil {
-			originalSource := sourcemap.ExtractOriginalSource(sourceMap)
-			if originalSource != "" {
-				// Add original source as additional file for analysis
-				jsFiles = append(jsFiles, JavaScriptFile{
-					URL:      jsURL + ".map.original",
-					FileName: fileName + ".original",
-					Content:  originalSource,
-				})
-				if config.Verbose {
-					fmt.Printf("[+] Extracted original source from source map (%d bytes)\n", len(originalSource))
-				}
-			}
+		sourceURL := sourceMap.SourceURL(i)
+		if !strings.HasPrefix(sourceURL, "http://") && !strings.HasPrefix(sourceURL, "https://") {
+			continue
 		}
 
-		// RECURSIVE: Extract more JS files from this JS file
-		newJSURLs := extractJSFromJSContent(content, jsURL, baseURLObj)
-		if len(newJSURLs) > 0 {
+		resp, err := config.Fetch(sourceURL)
+		if err != nil || resp.StatusCode != 200 {
 			if config.Verbose {
-				fmt.Printf("[*] Found %d additional JS files referenced in %s\n", len(newJSURLs), fileName)
-			}
-			for _, newURL := range newJSURLs {
-				if !seenURLs[newURL] {
-					queue = append(queue, newURL)
-				}
+				fmt.Printf("[!] Could not fetch original source: %s\n", sourceURL)
 			}
+			continue
 		}
+		sourceMap.SetSourceContent(i, resp.Body)
 	}
-
-	if config.Verbose {
-		fmt.Printf("[*] Total JavaScript files discovered: %d\n", len(jsFiles))
-	}
-
-	return jsFiles, nil
 }
 
 // extractJavaScriptSources extracts all JS file URLs from HTML
@@ -0,0 +1,48 @@
+package crawler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/0xhkx0/jsmap/pkg/client"
+)
+
+// fakeFetch serves pages from a map of URL to status code and body
+func fakeFetch(pages map[string]client.Response) func(string) (*client.Response, error) {
+	return func(u string) (*client.Response, error) {
+		page, ok := pages[u]
+		if !ok {
+			return &client.Response{URL: u, StatusCode: 404, Body: "<html>Not Found</html>"}, nil
+		}
+		page.URL = u
+		return &page, nil
+	}
+}
+
+func TestCrawlSkipsErrorPagesAndKeepsStatus(t *testing.T) {
+	base := "https://shop.example.org/"
+	config := &Config{
+		TargetURL: base,
+		Threads:   2,
+		Fetch: fakeFetch(map[string]client.Response{
+			base:               {StatusCode: 200, Body: `<script src="/app.js"></script><script src="/gone.js"></script><script src="/broken.js"></script>`},
+			base + "app.js":    {StatusCode: 203, Body: `fetch("/api/v1/cart")`},
+			base + "broken.js": {StatusCode: 500, Body: `<html>Internal Server Error</html>`},
+		}),
+	}
+
+	files, err := CrawlForJavaScript(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		var urls []string
+		for _, file := range files {
+			urls = append(urls, fmt.Sprintf("%s (%d)", file.URL, file.StatusCode))
+		}
+		t.Fatalf("got files %v, want only app.js", urls)
+	}
+	if files[0].URL != base+"app.js" || files[0].StatusCode != 203 {
+		t.Errorf("got %s with status %d, want app.js with status 203", files[0].URL, files[0].StatusCode)
+	}
+}
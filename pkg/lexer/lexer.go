@@ -0,0 +1,539 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a token
+type Kind int
+
+// Token kinds
+const (
+	EOF Kind = iota
+	Identifier
+	Keyword
+	Number
+	// String is a single or double quoted string literal
+	String
+	// Template is a template literal without substitutions, e.g. `/users`
+	Template
+	// TemplateHead, TemplateMiddle and TemplateTail are the text parts of a
+	// template literal with substitutions: `head${a}middle${b}tail`. The
+	// tokens of each substitution appear between them.
+	TemplateHead
+	TemplateMiddle
+	TemplateTail
+	Regex
+	Comment
+	Punctuator
+)
+
+// kindNames holds the name of each kind for String
+var kindNames = map[Kind]string{
+	EOF:            "EOF",
+	Identifier:     "Identifier",
+	Keyword:        "Keyword",
+	Number:         "Number",
+	String:         "String",
+	Template:       "Template",
+	TemplateHead:   "TemplateHead",
+	TemplateMiddle: "TemplateMiddle",
+	TemplateTail:   "TemplateTail",
+	Regex:          "Regex",
+	Comment:        "Comment",
+	Punctuator:     "Punctuator",
+}
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Token is a single lexical token. Offset and End are byte offsets of the
+// raw token in the source; Line and Column (1-based, counted in characters)
+// are the position of Offset.
+type Token struct {
+	Kind   Kind
+	Raw    string
+	Offset int
+	End    int
+	Line   int
+	Column int
+	// Value is the decoded text of string and template tokens, without
+	// quotes, backticks or ${ } delimiters. For other kinds it equals Raw.
+	Value string
+}
+
+// IsLiteral reports whether the token is a string or a part of a template literal
+func (t Token) IsLiteral() bool {
+	switch t.Kind {
+	case String, Template, TemplateHead, TemplateMiddle, TemplateTail:
+		return true
+	}
+	return false
+}
+
+// Is reports whether the token is the given punctuator, keyword or identifier
+func (t Token) Is(text string) bool {
+	switch t.Kind {
+	case Punctuator, Keyword, Identifier:
+		return t.Raw == text
+	}
+	return false
+}
+
+// keywords are reserved words. After most of them an expression starts, so
+// a following / begins a regular expression.
+var keywords = map[string]bool{
+	"await": true, "break": true, "case": true, "catch": true, "class": true,
+	"const": true, "continue": true, "debugger": true, "default": true,
+	"delete": true, "do": true, "else": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"import": true, "in": true, "instanceof": true, "let": true, "new": true,
+	"null": true, "return": true, "super": true, "switch": true, "this": true,
+	"throw": true, "true": true, "try": true, "typeof": true, "var": true,
+	"void": true, "while": true, "with": true, "yield": true,
+}
+
+// valueKeywords are keywords that end an expression, so a following / is division
+var valueKeywords = map[string]bool{
+	"false": true, "null": true, "super": true, "this": true, "true": true,
+}
+
+// punctuators lists every punctuator, longest first so the longest match wins
+var punctuators = []string{
+	">>>=",
+	"...", "===", "!==", "**=", "<<=", ">>=", ">>>", "&&=", "||=", "??=",
+	"=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>", "**",
+	"{", "}", "(", ")", "[", "]", ";", ",", "<", ">", "+", "-", "*", "/",
+	"%", "&", "|", "^", "!", "~", "?", ":", "=", ".", "@", "#",
+}
+
+// Lexer splits JavaScript source into tokens. It never fails: malformed
+// input such as an unterminated string yields a token running to the end
+// of the line or source, so minified and partially broken bundles can
+// still be scanned.
+type Lexer struct {
+	src    string
+	pos    int
+	line   int
+	column int
+
+	// last is the last token that was not a comment, used to tell
+	// regular expressions from division
+	last *Token
+	// braces tracks open { and ${ so a } can resume a template literal;
+	// true marks a template substitution
+	braces []bool
+}
+
+// New creates a lexer for the source
+func New(src string) *Lexer {
+	return &Lexer{src: src, line: 1, column: 1}
+}
+
+// Tokenize returns every token of the source, excluding the final EOF
+func Tokenize(src string) []Token {
+	l := New(src)
+	var tokens []Token
+	for {
+		tok := l.Next()
+		if tok.Kind == EOF {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+// Next returns the next token, or a token of kind EOF at the end of the source
+func (l *Lexer) Next() Token {
+	l.skipWhitespace()
+
+	start, line, column := l.pos, l.line, l.column
+	kind := l.scan()
+	tok := Token{
+		Kind:   kind,
+		Raw:    l.src[start:l.pos],
+		Offset: start,
+		End:    l.pos,
+		Line:   line,
+		Column: column,
+	}
+
+	switch kind {
+	case String:
+		tok.Value = unquote(tok.Raw[1:], tok.Raw[0])
+	case Template, TemplateTail:
+		tok.Value = unquote(tok.Raw[1:], '`')
+	case TemplateHead, TemplateMiddle:
+		tok.Value = unquote(strings.TrimSuffix(tok.Raw[1:], "${"), '`')
+	case Identifier:
+		if keywords[tok.Raw] {
+			tok.Kind = Keyword
+		}
+		tok.Value = tok.Raw
+	default:
+		tok.Value = tok.Raw
+	}
+
+	if tok.Kind != Comment && tok.Kind != EOF {
+		last := tok
+		l.last = &last
+	}
+	return tok
+}
+
+// scan consumes one token and returns its kind
+func (l *Lexer) scan() Kind {
+	if l.pos >= len(l.src) {
+		return EOF
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case c == '"' || c == '\'':
+		l.scanString(c)
+		return String
+	case c == '`':
+		l.advance(1)
+		return l.scanTemplate(Template, TemplateHead)
+	case c == '/' && l.peek(1) == '/':
+		l.scanLineComment()
+		return Comment
+	case c == '/' && l.peek(1) == '*':
+		l.scanBlockComment()
+		return Comment
+	case c == '#' && l.pos == 0 && l.peek(1) == '!':
+		l.scanLineComment()
+		return Comment
+	case c == '/' && l.regexAllowed():
+		l.scanRegex()
+		return Regex
+	case c >= '0' && c <= '9', c == '.' && isDigit(l.peek(1)):
+		l.scanNumber()
+		return Number
+	case c == '}' && len(l.braces) > 0 && l.braces[len(l.braces)-1]:
+		l.braces = l.braces[:len(l.braces)-1]
+		l.advance(1)
+		return l.scanTemplate(TemplateTail, TemplateMiddle)
+	}
+
+	if r, size := utf8.DecodeRuneInString(l.src[l.pos:]); isIdentifierStart(r) {
+		l.advance(size)
+		l.scanIdentifierPart()
+		return Identifier
+	}
+
+	for _, p := range punctuators {
+		if strings.HasPrefix(l.src[l.pos:], p) {
+			switch p {
+			case "{":
+				l.braces = append(l.braces, false)
+			case "}":
+				if len(l.braces) > 0 {
+					l.braces = l.braces[:len(l.braces)-1]
+				}
+			}
+			l.advance(len(p))
+			return Punctuator
+		}
+	}
+
+	// Unknown character, e.g. a stray backslash: emit it as a punctuator
+	_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+	l.advance(size)
+	return Punctuator
+}
+
+// regexAllowed reports whether a / at the current position starts a regular
+// expression rather than a division, based on the previous token
+func (l *Lexer) regexAllowed() bool {
+	if l.last == nil {
+		return true
+	}
+	switch l.last.Kind {
+	case Identifier, Number, String, Template, TemplateTail, Regex:
+		return false
+	case Keyword:
+		return !valueKeywords[l.last.Raw]
+	case Punctuator:
+		switch l.last.Raw {
+		case ")", "]", "}", "++", "--":
+			return false
+		}
+	}
+	return true
+}
+
+// scanString consumes a string literal opened by quote. An unescaped line
+// break ends an unterminated string.
+func (l *Lexer) scanString(quote byte) {
+	l.advance(1)
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case quote:
+			l.advance(1)
+			return
+		case '\\':
+			l.advance(1)
+			if strings.HasPrefix(l.src[l.pos:], "\r\n") {
+				// Line continuation
+				l.advance(2)
+			} else if l.pos < len(l.src) {
+				_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+				l.advance(size)
+			}
+		case '\n', '\r':
+			return
+		default:
+			l.advance(1)
+		}
+	}
+}
+
+// scanTemplate consumes template text after a backtick or the } closing a
+// substitution. It returns closed if the template ends and open if a
+// substitution starts.
+func (l *Lexer) scanTemplate(closed, open Kind) Kind {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '`':
+			l.advance(1)
+			return closed
+		case c == '\\':
+			l.advance(1)
+			if l.pos < len(l.src) {
+				_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+				l.advance(size)
+			}
+		case c == '$' && l.peek(1) == '{':
+			l.advance(2)
+			l.braces = append(l.braces, true)
+			return open
+		default:
+			l.advance(1)
+		}
+	}
+	return closed
+}
+
+// scanRegex consumes a regular expression literal and its flags
+func (l *Lexer) scanRegex() {
+	l.advance(1)
+	inClass := false
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '\n' || c == '\r' {
+			return
+		}
+		l.advance(1)
+		switch {
+		case c == '\\' && l.pos < len(l.src):
+			l.advance(1)
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			l.scanIdentifierPart()
+			return
+		}
+	}
+}
+
+// scanLineComment consumes a comment up to the end of the line
+func (l *Lexer) scanLineComment() {
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+		l.advance(1)
+	}
+}
+
+// scanBlockComment consumes a /* */ comment
+func (l *Lexer) scanBlockComment() {
+	l.advance(2)
+	for l.pos < len(l.src) {
+		if l.src[l.pos] == '*' && l.peek(1) == '/' {
+			l.advance(2)
+			return
+		}
+		l.advance(1)
+	}
+}
+
+// scanNumber consumes a numeric literal, including hex, octal, binary,
+// exponents, separators and BigInt suffixes
+func (l *Lexer) scanNumber() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case isDigit(c), isLetter(c), c == '_', c == '.':
+			l.advance(1)
+		case (c == '+' || c == '-') && l.pos > 0 && (l.src[l.pos-1] == 'e' || l.src[l.pos-1] == 'E') && !isHexNumber(l.src, l.pos):
+			l.advance(1)
+		default:
+			return
+		}
+	}
+}
+
+// scanIdentifierPart consumes the rest of an identifier or regex flags
+func (l *Lexer) scanIdentifierPart() {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !isIdentifierPart(r) {
+			return
+		}
+		l.advance(size)
+	}
+}
+
+// skipWhitespace consumes whitespace and line terminators
+func (l *Lexer) skipWhitespace() {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if r != '\uFEFF' && !unicode.IsSpace(r) {
+			return
+		}
+		l.advance(size)
+	}
+}
+
+// advance moves n bytes forward, tracking line and column
+func (l *Lexer) advance(n int) {
+	for end := l.pos + n; l.pos < end && l.pos < len(l.src); l.pos++ {
+		switch c := l.src[l.pos]; {
+		case c == '\n':
+			l.line++
+			l.column = 1
+		case c == '\r':
+			if l.pos+1 >= len(l.src) || l.src[l.pos+1] != '\n' {
+				l.line++
+				l.column = 1
+			}
+		case utf8.RuneStart(c):
+			l.column++
+		}
+	}
+}
+
+// peek returns the byte n positions ahead, or 0 past the end of the source
+func (l *Lexer) peek(n int) byte {
+	if l.pos+n < len(l.src) {
+		return l.src[l.pos+n]
+	}
+	return 0
+}
+
+// isHexNumber reports whether the number ending before pos is a hex literal,
+// where e and E are digits rather than exponents
+func isHexNumber(src string, pos int) bool {
+	start := pos
+	for start > 0 && (isDigit(src[start-1]) || isLetter(src[start-1]) || src[start-1] == '_' || src[start-1] == '.') {
+		start--
+	}
+	return strings.HasPrefix(src[start:pos], "0x") || strings.HasPrefix(src[start:pos], "0X")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '$' || r == '_' || r < utf8.RuneSelf && isLetter(byte(r)) || r >= utf8.RuneSelf && unicode.IsLetter(r)
+}
+
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || r >= '0' && r <= '9' || r == '\u200C' || r == '\u200D' ||
+		r >= utf8.RuneSelf && (unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r))
+}
+
+// unquote decodes the escapes of string or template text up to the closing
+// quote. Invalid escapes are kept as the escaped character.
+func unquote(text string, quote byte) string {
+	text = strings.TrimSuffix(text, string(quote))
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c != '\\' || i+1 >= len(text) {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch e := text[i]; e {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\r':
+			// Line continuation
+			if i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+			// Line continuation
+		case 'x':
+			if r, ok := parseHex(text, i+1, 2); ok {
+				b.WriteRune(r)
+				i += 2
+			} else {
+				b.WriteByte(e)
+			}
+		case 'u':
+			if i+1 < len(text) && text[i+1] == '{' {
+				if end := strings.IndexByte(text[i:], '}'); end > 0 {
+					if r, err := strconv.ParseUint(text[i+2:i+end], 16, 32); err == nil {
+						b.WriteRune(rune(r))
+						i += end
+						continue
+					}
+				}
+			} else if r, ok := parseHex(text, i+1, 4); ok {
+				b.WriteRune(r)
+				i += 4
+				continue
+			}
+			b.WriteByte(e)
+		default:
+			b.WriteByte(e)
+		}
+	}
+	return b.String()
+}
+
+// parseHex parses n hex digits at text[start:]
+func parseHex(text string, start, n int) (rune, bool) {
+	if start+n > len(text) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(text[start:start+n], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(v), true
+}
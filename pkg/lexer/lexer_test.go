@@ -0,0 +1,253 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// kinds returns the kind and raw text of every token of src
+func kinds(src string) []string {
+	var result []string
+	for _, tok := range Tokenize(src) {
+		result = append(result, tok.Kind.String()+" "+tok.Raw)
+	}
+	return result
+}
+
+func TestRegexOrDivision(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "after identifier",
+			src:  "a / b / c",
+			want: []string{"Identifier a", "Punctuator /", "Identifier b", "Punctuator /", "Identifier c"},
+		},
+		{
+			name: "after closing paren",
+			src:  "f(x) / 2 / y",
+			want: []string{"Identifier f", "Punctuator (", "Identifier x", "Punctuator )", "Punctuator /", "Number 2", "Punctuator /", "Identifier y"},
+		},
+		{
+			name: "after closing bracket",
+			src:  "a[0] / 2",
+			want: []string{"Identifier a", "Punctuator [", "Number 0", "Punctuator ]", "Punctuator /", "Number 2"},
+		},
+		{
+			name: "after keyword",
+			src:  "return /ab+c/g",
+			want: []string{"Keyword return", "Regex /ab+c/g"},
+		},
+		{
+			name: "after typeof",
+			src:  "typeof /x/",
+			want: []string{"Keyword typeof", "Regex /x/"},
+		},
+		{
+			name: "after value keyword",
+			src:  "this / 2",
+			want: []string{"Keyword this", "Punctuator /", "Number 2"},
+		},
+		{
+			name: "after assignment",
+			src:  `x = /a\/b[/]c/i`,
+			want: []string{"Identifier x", "Punctuator =", `Regex /a\/b[/]c/i`},
+		},
+		{
+			name: "at start",
+			src:  "/^\\/api/.test(p)",
+			want: []string{`Regex /^\/api/`, "Punctuator .", "Identifier test", "Punctuator (", "Identifier p", "Punctuator )"},
+		},
+		{
+			name: "after postfix increment",
+			src:  "i++ / 2",
+			want: []string{"Identifier i", "Punctuator ++", "Punctuator /", "Number 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kinds(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "no substitutions",
+			src:  "`/users`",
+			want: []string{"Template `/users`"},
+		},
+		{
+			name: "substitutions",
+			src:  "`/a/${id}/b/${x}`",
+			want: []string{"TemplateHead `/a/${", "Identifier id", "TemplateMiddle }/b/${", "Identifier x", "TemplateTail }`"},
+		},
+		{
+			name: "nested template",
+			src:  "`${a ? `${b}` : c}`",
+			want: []string{
+				"TemplateHead `${", "Identifier a", "Punctuator ?",
+				"TemplateHead `${", "Identifier b", "TemplateTail }`",
+				"Punctuator :", "Identifier c", "TemplateTail }`",
+			},
+		},
+		{
+			name: "object literal in substitution",
+			src:  "`${ {a: 1}.a }x`",
+			want: []string{
+				"TemplateHead `${", "Punctuator {", "Identifier a", "Punctuator :", "Number 1", "Punctuator }",
+				"Punctuator .", "Identifier a", "TemplateTail }x`",
+			},
+		},
+		{
+			name: "escaped substitution",
+			src:  "`\\${x}`",
+			want: []string{"Template `\\${x}`"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kinds(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		kind Kind
+		want string
+	}{
+		{"escaped quote", `"a\"b"`, String, `a"b`},
+		{"single quoted", `'it\'s'`, String, "it's"},
+		{"control escapes", `"a\tb\nc"`, String, "a\tb\nc"},
+		{"hex and unicode escapes", `'\x41B\u{43}'`, String, "ABC"},
+		{"invalid escapes", `"\x4g\q"`, String, "x4gq"},
+		{"line continuation", "\"/api\\\n/users\"", String, "/api/users"},
+		{"crlf line continuation", "\"/api\\\r\n/users\"", String, "/api/users"},
+		{"template escapes", "`\\`a\\${b}`", Template, "`a${b}"},
+		{"template head", "`/a\\u002f${", TemplateHead, "/a/"},
+		{"template line continuation", "`a\\\nb`", Template, "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := Tokenize(tt.src)
+			if len(tokens) == 0 {
+				t.Fatalf("Tokenize(%q) returned no tokens", tt.src)
+			}
+			if tokens[0].Kind != tt.kind || tokens[0].Value != tt.want {
+				t.Errorf("Tokenize(%q)[0] = %s %q, want %s %q", tt.src, tokens[0].Kind, tokens[0].Value, tt.kind, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnterminated(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "string ends at line break",
+			src:  "\"abc\nx",
+			want: []string{"String \"abc", "Identifier x"},
+		},
+		{
+			name: "string ends at end of source",
+			src:  "'abc",
+			want: []string{"String 'abc"},
+		},
+		{
+			name: "string ending in backslash",
+			src:  `"abc\`,
+			want: []string{`String "abc\`},
+		},
+		{
+			name: "block comment",
+			src:  "a /* abc\ndef",
+			want: []string{"Identifier a", "Comment /* abc\ndef"},
+		},
+		{
+			name: "line comment at end of source",
+			src:  "a // abc",
+			want: []string{"Identifier a", "Comment // abc"},
+		},
+		{
+			name: "template",
+			src:  "`abc\ndef",
+			want: []string{"Template `abc\ndef"},
+		},
+		{
+			name: "template substitution",
+			src:  "`a${b",
+			want: []string{"TemplateHead `a${", "Identifier b"},
+		},
+		{
+			name: "regex ends at line break",
+			src:  "x = /abc\ny",
+			want: []string{"Identifier x", "Punctuator =", "Regex /abc", "Identifier y"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kinds(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	src := "a = 1;\r\n  s = \"é\" + `x${y}`\n/* c */ b"
+	want := []struct {
+		raw                       string
+		offset, end, line, column int
+	}{
+		{"a", 0, 1, 1, 1},
+		{"=", 2, 3, 1, 3},
+		{"1", 4, 5, 1, 5},
+		{";", 5, 6, 1, 6},
+		{"s", 10, 11, 2, 3},
+		{"=", 12, 13, 2, 5},
+		{`"é"`, 14, 18, 2, 7},
+		{"+", 19, 20, 2, 11},
+		{"`x${", 21, 25, 2, 13},
+		{"y", 25, 26, 2, 17},
+		{"}`", 26, 28, 2, 18},
+		{"/* c */", 29, 36, 3, 1},
+		{"b", 37, 38, 3, 9},
+	}
+
+	tokens := Tokenize(src)
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %q", len(tokens), len(want), kinds(src))
+	}
+	for i, w := range want {
+		tok := tokens[i]
+		if tok.Raw != w.raw || tok.Offset != w.offset || tok.End != w.end || tok.Line != w.line || tok.Column != w.column {
+			t.Errorf("token %d = %q [%d:%d] %d:%d, want %q [%d:%d] %d:%d",
+				i, tok.Raw, tok.Offset, tok.End, tok.Line, tok.Column,
+				w.raw, w.offset, w.end, w.line, w.column)
+		}
+		if src[tok.Offset:tok.End] != tok.Raw {
+			t.Errorf("token %d: src[%d:%d] = %q, want raw %q", i, tok.Offset, tok.End, src[tok.Offset:tok.End], tok.Raw)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package output
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xhkx0/jsmap/pkg/client"
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// postmanSchema is the schema URL identifying a Postman v2.1 collection
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanBaseURL is the collection variable relative endpoints resolve
+// against when the scan has no origin, e.g. for local files
+const postmanBaseURL = "{{baseUrl}}"
+
+// exportHeader is a header of an exported request
+type exportHeader struct {
+	name  string
+	value string
+}
+
+// exportRequest is a request to a discovered endpoint, resolved against the
+// origin it was found on and carrying the scan's auth context
+type exportRequest struct {
+	name    string
+	method  string
+	server  string
+	path    string
+	params  []string
+	query   []string
+	headers []exportHeader
+	body    string
+}
+
+// exportRequests builds one request per method of every endpoint. Relative
+// endpoints are resolved against the origins of their sources, or against
+// target when they have none; without either the server is left empty.
+func exportRequests(af *types.AggregatedFindings, config *client.Config, target string) []exportRequest {
+	fallback := ""
+	if u, err := url.Parse(target); err == nil && u.Scheme != "" && u.Host != "" {
+		fallback = u.Scheme + "://" + u.Host
+	}
+
+	var requests []exportRequest
+	for _, finding := range af.ByCategory(types.CategoryEndpoints) {
+		servers, path := splitEndpoint(finding.Value, finding.Sources)
+		if len(servers) == 1 && servers[0] == "/" {
+			servers = []string{fallback}
+		}
+
+		request := finding.Request
+		if request == nil {
+			request = &types.Request{}
+		}
+		methods := request.Methods
+		if len(methods) == 0 {
+			methods = []string{"GET"}
+		}
+
+		for _, server := range servers {
+			for _, method := range methods {
+				req := exportRequest{
+					name:    method + " " + path,
+					method:  method,
+					server:  server,
+					path:    path,
+					params:  pathParams(path),
+					query:   request.Query,
+					headers: authHeaders(config),
+				}
+				for _, name := range request.Headers {
+					req.addHeader(name, "")
+				}
+				if len(request.Body) > 0 && bodyMethods[method] {
+					req.body = jsonBody(request.Body)
+					req.addHeader("Content-Type", "application/json")
+				}
+				requests = append(requests, req)
+			}
+		}
+	}
+	return requests
+}
+
+// authHeaders returns the User-Agent, cookie and custom headers of the scan
+// in a stable order
+func authHeaders(config *client.Config) []exportHeader {
+	if config == nil {
+		return nil
+	}
+
+	var headers []exportHeader
+	if config.UserAgent != "" {
+		headers = append(headers, exportHeader{"User-Agent", config.UserAgent})
+	}
+	if config.Cookie != "" {
+		headers = append(headers, exportHeader{"Cookie", config.Cookie})
+	}
+	names := make([]string, 0, len(config.Headers))
+	for name := range config.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		headers = append(headers, exportHeader{name, config.Headers[name]})
+	}
+	return headers
+}
+
+// addHeader adds a header unless the request already sets it
+func (r *exportRequest) addHeader(name, value string) {
+	for _, header := range r.headers {
+		if strings.EqualFold(header.name, name) {
+			return
+		}
+	}
+	r.headers = append(r.headers, exportHeader{name, value})
+}
+
+// jsonBody renders body keys as a JSON object with empty values, keeping
+// the order the keys were found in
+func jsonBody(keys []string) string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		name, _ := json.Marshal(key)
+		b.Write(name)
+		b.WriteString(`: ""`)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// rawQuery renders query parameter names with empty values
+func rawQuery(names []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = url.QueryEscape(name) + "="
+	}
+	return strings.Join(pairs, "&")
+}
+
+// postmanCollection is a Postman v2.1 collection
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanFolder   `json:"item"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+// postmanInfo describes a Postman collection
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// postmanFolder groups the requests to one server
+type postmanFolder struct {
+	Name string        `json:"name"`
+	Item []postmanItem `json:"item"`
+}
+
+// postmanItem is a single request of a collection
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+// postmanRequest is the request of a collection item
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanVariable `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body,omitempty"`
+}
+
+// postmanURL is a request URL split into the parts Postman edits
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol,omitempty"`
+	Host     []string          `json:"host"`
+	Port     string            `json:"port,omitempty"`
+	Path     []string          `json:"path,omitempty"`
+	Query    []postmanVariable `json:"query,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+// postmanBody is a raw request body
+type postmanBody struct {
+	Mode    string                 `json:"mode"`
+	Raw     string                 `json:"raw"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// postmanVariable is a key and value, used for headers, query parameters
+// and variables alike
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// AggregatedToPostman converts the endpoints of aggregated findings to a
+// Postman v2.1 collection with one folder per server and one request per
+// endpoint and method. Requests carry the cookie and headers the scan used;
+// {name} path parameters become Postman :name variables.
+func AggregatedToPostman(af *types.AggregatedFindings, config *client.Config, target string) string {
+	collection := postmanCollection{
+		Info: postmanInfo{
+			Name:        "jsmap discovered endpoints",
+			Description: fmt.Sprintf("Endpoints extracted from %d JavaScript sources", len(af.Sources)),
+			Schema:      postmanSchema,
+		},
+		Item: []postmanFolder{},
+	}
+
+	folders := make(map[string]int)
+	for _, req := range exportRequests(af, config, target) {
+		server := req.server
+		if server == "" {
+			server = postmanBaseURL
+			if collection.Variable == nil {
+				collection.Variable = []postmanVariable{{Key: "baseUrl", Value: ""}}
+			}
+		}
+
+		i, exists := folders[server]
+		if !exists {
+			i = len(collection.Item)
+			folders[server] = i
+			collection.Item = append(collection.Item, postmanFolder{Name: server})
+		}
+		collection.Item[i].Item = append(collection.Item[i].Item, newPostmanItem(req, server))
+	}
+
+	jsonBytes, _ := json.MarshalIndent(collection, "", "  ")
+	return string(jsonBytes)
+}
+
+// newPostmanItem builds the collection item of a request to server
+func newPostmanItem(req exportRequest, server string) postmanItem {
+	path := req.path
+	for _, param := range req.params {
+		path = strings.ReplaceAll(path, "{"+param+"}", ":"+param)
+	}
+
+	u := postmanURL{
+		Raw:  server + path,
+		Host: []string{server},
+		Path: strings.Split(strings.TrimPrefix(path, "/"), "/"),
+	}
+	// Postman keeps the scheme and port apart from the host segments
+	if parsed, err := url.Parse(server); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		u.Protocol = parsed.Scheme
+		u.Host = strings.Split(parsed.Hostname(), ".")
+		u.Port = parsed.Port()
+	}
+	if len(req.query) > 0 {
+		u.Raw += "?" + rawQuery(req.query)
+	}
+	for _, name := range req.query {
+		u.Query = append(u.Query, postmanVariable{Key: name})
+	}
+	for _, param := range req.params {
+		u.Variable = append(u.Variable, postmanVariable{Key: param})
+	}
+
+	item := postmanItem{
+		Name: req.name,
+		Request: postmanRequest{
+			Method: req.method,
+			Header: []postmanVariable{},
+			URL:    u,
+		},
+	}
+	for _, header := range req.headers {
+		item.Request.Header = append(item.Request.Header, postmanVariable{Key: header.name, Value: header.value})
+	}
+	if req.body != "" {
+		item.Request.Body = &postmanBody{
+			Mode:    "raw",
+			Raw:     req.body,
+			Options: map[string]interface{}{"raw": map[string]string{"language": "json"}},
+		}
+	}
+	return item
+}
+
+// AggregatedToBurp converts the endpoints of aggregated findings to the XML
+// item list Burp Suite saves and imports, with one base64 raw request per
+// endpoint and method. Requests carry the cookie and headers the scan used.
+// Endpoints that cannot be resolved to an origin are left out.
+func AggregatedToBurp(af *types.AggregatedFindings, config *client.Config, target string) string {
+	var output strings.Builder
+	output.WriteString(`<?xml version="1.0"?>` + "\n")
+	output.WriteString(fmt.Sprintf("<items burpVersion=\"\" exportTime=\"%s\">\n", time.Now().Format("Mon Jan 02 15:04:05 MST 2006")))
+
+	for _, req := range exportRequests(af, config, target) {
+		u, err := url.Parse(req.server)
+		if req.server == "" || err != nil || u.Host == "" {
+			continue
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		path := escapePath(req.path)
+		if len(req.query) > 0 {
+			path += "?" + rawQuery(req.query)
+		}
+
+		output.WriteString("  <item>\n")
+		output.WriteString(fmt.Sprintf("    <url>%s</url>\n", cdata(req.server+path)))
+		output.WriteString(fmt.Sprintf("    <host ip=\"\">%s</host>\n", xmlEscape(u.Hostname())))
+		output.WriteString(fmt.Sprintf("    <port>%s</port>\n", port))
+		output.WriteString(fmt.Sprintf("    <protocol>%s</protocol>\n", xmlEscape(u.Scheme)))
+		output.WriteString(fmt.Sprintf("    <method>%s</method>\n", cdata(req.method)))
+		output.WriteString(fmt.Sprintf("    <path>%s</path>\n", cdata(path)))
+		output.WriteString(fmt.Sprintf("    <request base64=\"true\">%s</request>\n", cdata(base64.StdEncoding.EncodeToString([]byte(rawRequest(req, u.Host, path))))))
+		output.WriteString("    <comment>jsmap</comment>\n")
+		output.WriteString("  </item>\n")
+	}
+
+	output.WriteString("</items>\n")
+	return output.String()
+}
+
+// rawRequest renders a request as raw HTTP/1.1
+func rawRequest(req exportRequest, host, path string) string {
+	var b strings.Builder
+	b.WriteString(req.method + " " + path + " HTTP/1.1\r\n")
+	b.WriteString("Host: " + host + "\r\n")
+	for _, header := range req.headers {
+		b.WriteString(header.name + ": " + header.value + "\r\n")
+	}
+	if req.body != "" {
+		b.WriteString(fmt.Sprintf("Content-Length: %d\r\n", len(req.body)))
+	}
+	b.WriteString("\r\n")
+	b.WriteString(req.body)
+	return b.String()
+}
+
+// escapePath percent-encodes the characters of a path template that are
+// not valid in a request line, such as the braces of {name} parameters
+func escapePath(path string) string {
+	return (&url.URL{Path: path}).EscapedPath()
+}
+
+// cdata wraps text in a CDATA section
+func cdata(text string) string {
+	return "<![CDATA[" + strings.ReplaceAll(text, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
+
+// xmlEscape escapes text for an XML element
+func xmlEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(text)
+}
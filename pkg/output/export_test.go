@@ -0,0 +1,154 @@
+package output
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/0xhkx0/jsmap/pkg/client"
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// exportConfig is the auth context of a scan
+var exportConfig = &client.Config{
+	UserAgent: "jsmap-test",
+	Cookie:    "session=abc123",
+	Headers:   map[string]string{"X-Api-Key": "k3y", "Authorization": "Bearer t0k"},
+}
+
+// exportFindings returns a POST endpoint with a path parameter, query and
+// body found in source
+func exportFindings(source, url string) *types.AggregatedFindings {
+	findings := types.NewFindings(false)
+	findings.Add(types.Finding{
+		Category: types.CategoryEndpoints,
+		Value:    "/api/users/{id}",
+		Params:   []string{"id"},
+		Location: types.Location{Line: 1, Column: 1},
+		Request: &types.Request{
+			Methods: []string{"POST"},
+			Query:   []string{"v"},
+			Body:    []string{"name"},
+		},
+	})
+	af := types.NewAggregatedFindings()
+	af.AddFindings(findings, source, url, 200)
+	return af
+}
+
+// postmanOf renders and parses a Postman collection
+func postmanOf(t *testing.T, af *types.AggregatedFindings, target string) postmanCollection {
+	t.Helper()
+	var collection postmanCollection
+	if err := json.Unmarshal([]byte(AggregatedToPostman(af, exportConfig, target)), &collection); err != nil {
+		t.Fatal(err)
+	}
+	return collection
+}
+
+func TestPostmanRequest(t *testing.T) {
+	collection := postmanOf(t, exportFindings("main.js", "https://shop.example.org:8443/static/main.js"), "")
+	if len(collection.Item) != 1 || len(collection.Item[0].Item) != 1 {
+		t.Fatalf("items = %+v, want one folder with one request", collection.Item)
+	}
+	if collection.Variable != nil {
+		t.Errorf("variables = %+v, want none for a resolved origin", collection.Variable)
+	}
+
+	request := collection.Item[0].Item[0].Request
+	wantURL := postmanURL{
+		Raw:      "https://shop.example.org:8443/api/users/:id?v=",
+		Protocol: "https",
+		Host:     []string{"shop", "example", "org"},
+		Port:     "8443",
+		Path:     []string{"api", "users", ":id"},
+		Query:    []postmanVariable{{Key: "v"}},
+		Variable: []postmanVariable{{Key: "id"}},
+	}
+	if !reflect.DeepEqual(request.URL, wantURL) {
+		t.Errorf("url = %+v, want %+v", request.URL, wantURL)
+	}
+
+	wantHeaders := []postmanVariable{
+		{Key: "User-Agent", Value: "jsmap-test"},
+		{Key: "Cookie", Value: "session=abc123"},
+		{Key: "Authorization", Value: "Bearer t0k"},
+		{Key: "X-Api-Key", Value: "k3y"},
+		{Key: "Content-Type", Value: "application/json"},
+	}
+	if !reflect.DeepEqual(request.Header, wantHeaders) {
+		t.Errorf("headers = %+v, want %+v", request.Header, wantHeaders)
+	}
+	if request.Method != "POST" || request.Body == nil || request.Body.Raw != `{"name": ""}` {
+		t.Errorf("request = %s %+v, want POST with a JSON body", request.Method, request.Body)
+	}
+}
+
+func TestPostmanBaseURLFallback(t *testing.T) {
+	collection := postmanOf(t, exportFindings("bundle.js", ""), "")
+	if len(collection.Item) != 1 || collection.Item[0].Name != postmanBaseURL {
+		t.Fatalf("folders = %+v, want one %s folder", collection.Item, postmanBaseURL)
+	}
+	if want := []postmanVariable{{Key: "baseUrl"}}; !reflect.DeepEqual(collection.Variable, want) {
+		t.Errorf("variables = %+v, want %+v", collection.Variable, want)
+	}
+
+	u := collection.Item[0].Item[0].Request.URL
+	if u.Raw != "{{baseUrl}}/api/users/:id?v=" || u.Protocol != "" || !reflect.DeepEqual(u.Host, []string{postmanBaseURL}) {
+		t.Errorf("url = %+v, want it relative to %s", u, postmanBaseURL)
+	}
+
+	// A target URL takes precedence over the variable
+	collection = postmanOf(t, exportFindings("bundle.js", ""), "http://localhost:3000/app")
+	if collection.Item[0].Name != "http://localhost:3000" || collection.Variable != nil {
+		t.Errorf("folder = %q, variables = %+v, want the target origin", collection.Item[0].Name, collection.Variable)
+	}
+}
+
+func TestBurpRawRequest(t *testing.T) {
+	output := AggregatedToBurp(exportFindings("main.js", "https://shop.example.org/main.js"), exportConfig, "")
+
+	match := regexp.MustCompile(`<request base64="true"><!\[CDATA\[([^\]]*)\]\]></request>`).FindStringSubmatch(output)
+	if match == nil {
+		t.Fatalf("no base64 request in:\n%s", output)
+	}
+	raw, err := base64.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "POST /api/users/%7Bid%7D?v= HTTP/1.1\r\n" +
+		"Host: shop.example.org\r\n" +
+		"User-Agent: jsmap-test\r\n" +
+		"Cookie: session=abc123\r\n" +
+		"Authorization: Bearer t0k\r\n" +
+		"X-Api-Key: k3y\r\n" +
+		"Content-Type: application/json\r\n" +
+		"Content-Length: 12\r\n" +
+		"\r\n" +
+		`{"name": ""}`
+	if string(raw) != want {
+		t.Errorf("raw request = %q, want %q", raw, want)
+	}
+
+	for _, element := range []string{
+		"<host ip=\"\">shop.example.org</host>",
+		"<port>443</port>",
+		"<protocol>https</protocol>",
+		"<url><![CDATA[https://shop.example.org/api/users/%7Bid%7D?v=]]></url>",
+	} {
+		if !strings.Contains(output, element) {
+			t.Errorf("output lacks %s", element)
+		}
+	}
+}
+
+func TestBurpSkipsUnresolvedEndpoints(t *testing.T) {
+	output := AggregatedToBurp(exportFindings("bundle.js", ""), exportConfig, "")
+	if strings.Contains(output, "<item>") {
+		t.Errorf("endpoint without an origin exported:\n%s", output)
+	}
+}
@@ -0,0 +1,116 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// jsonlSource is a source record of JSONL output
+type jsonlSource struct {
+	Record     string `json:"record"`
+	Source     string `json:"source"`
+	URL        string `json:"url,omitempty"`
+	StatusCode int    `json:"status_code"`
+	Findings   int    `json:"findings"`
+}
+
+// jsonlFinding is a finding record of JSONL output. Sources holds only the
+// source the record was emitted for; Count is the number of sources the
+// finding has been seen in so far.
+type jsonlFinding struct {
+	Record string `json:"record"`
+	jsonFinding
+}
+
+// JSONLWriter streams aggregated findings as JSON Lines: a "source" record
+// for every analyzed source followed by a "finding" record for each finding
+// in it. A finding seen in several sources is written once per source with
+// the same id.
+type JSONLWriter struct {
+	mu        sync.Mutex
+	enc       *json.Encoder
+	redaction Redaction
+	secrets   []types.Finding
+	seen      map[string]bool
+	redact    *redactor
+	err       error
+}
+
+// NewJSONLWriter creates a JSONL writer applying the redaction policy
+func NewJSONLWriter(w io.Writer, redaction Redaction) *JSONLWriter {
+	return &JSONLWriter{
+		enc:       json.NewEncoder(w),
+		redaction: redaction,
+		seen:      make(map[string]bool),
+		redact:    newRedactor(redaction, nil),
+	}
+}
+
+// Attach makes the writer stream everything added to af from now on
+func (jw *JSONLWriter) Attach(af *types.AggregatedFindings) {
+	af.OnAdd = jw.Write
+}
+
+// Write writes the record of a source and of the findings it contributed
+// to. Secrets are redacted in snippets of this and later records once they
+// have been seen.
+func (jw *JSONLWriter) Write(source types.SourceFinding, findings []*types.AggregatedFinding) {
+	jw.mu.Lock()
+	defer jw.mu.Unlock()
+
+	jw.learnSecrets(findings)
+	jw.encode(jsonlSource{
+		Record:     "source",
+		Source:     source.Source,
+		URL:        source.URL,
+		StatusCode: source.StatusCode,
+		Findings:   source.Count,
+	})
+
+	for _, finding := range findings {
+		redacted := jw.redact.aggregated(finding)
+		var sources []types.SourceFinding
+		for _, src := range redacted.Sources {
+			if src.Source == source.Source && src.URL == source.URL {
+				sources = append(sources, src)
+			}
+		}
+
+		record := jsonlFinding{Record: "finding", jsonFinding: newJSONFinding(redacted.Finding, sources)}
+		record.Count = len(finding.Sources)
+		jw.encode(record)
+	}
+}
+
+// learnSecrets adds the secrets among findings to those redacted
+func (jw *JSONLWriter) learnSecrets(findings []*types.AggregatedFinding) {
+	learned := false
+	for _, finding := range findings {
+		if finding.Category == types.CategorySecrets && !jw.seen[finding.ID] {
+			jw.seen[finding.ID] = true
+			jw.secrets = append(jw.secrets, finding.Finding)
+			learned = true
+		}
+	}
+	if learned {
+		jw.redact = newRedactor(jw.redaction, append([]types.Finding(nil), jw.secrets...))
+	}
+}
+
+// encode writes a record, keeping the first error
+func (jw *JSONLWriter) encode(record interface{}) {
+	if jw.err != nil {
+		return
+	}
+	jw.err = jw.enc.Encode(record)
+}
+
+// Err returns the first error writing a record
+func (jw *JSONLWriter) Err() error {
+	jw.mu.Lock()
+	defer jw.mu.Unlock()
+	return jw.err
+}
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// jsonlRecord holds the fields of source and finding records that are checked
+type jsonlRecord struct {
+	Record   string       `json:"record"`
+	Source   string       `json:"source"`
+	URL      string       `json:"url"`
+	Findings int          `json:"findings"`
+	ID       string       `json:"id"`
+	Category string       `json:"category"`
+	Value    string       `json:"value"`
+	Count    int          `json:"count"`
+	Sources  []jsonSource `json:"sources"`
+}
+
+// streamJSONL streams two sources sharing a URL finding, the first also
+// holding the secret embedded in it, and returns the parsed records
+func streamJSONL(t *testing.T, redaction Redaction) ([]jsonlRecord, string) {
+	t.Helper()
+	const secret = "vBqbwq7sdTWx6u"
+	repo := "https://deploy:" + secret + "@git.internal.corp/shop.git"
+
+	var buf bytes.Buffer
+	jw := NewJSONLWriter(&buf, redaction)
+	af := types.NewAggregatedFindings()
+	jw.Attach(af)
+
+	first := types.NewFindings(false)
+	first.Add(types.Finding{
+		Category: types.CategorySecrets,
+		RuleID:   "basic-auth-url",
+		Value:    "https://deploy:" + secret + "@git.internal.corp",
+		Masked:   "https://de...corp",
+		Location: types.Location{Line: 1, Column: 1, Snippet: `"` + repo + `"`},
+	})
+	first.Add(types.Finding{
+		Category: types.CategoryURLs,
+		RuleID:   "url",
+		Value:    repo,
+		Location: types.Location{Line: 1, Column: 2, Snippet: `"` + repo + `"`},
+	})
+	af.AddFindings(first, "app.js", "https://shop.example.org/app.js", 200)
+
+	second := types.NewFindings(false)
+	second.Add(types.Finding{
+		Category: types.CategoryURLs,
+		RuleID:   "url",
+		Value:    repo,
+		Location: types.Location{Line: 3, Column: 7, Snippet: `clone("` + repo + `")`},
+	})
+	af.AddFindings(second, "vendor.js", "https://shop.example.org/vendor.js", 200)
+
+	if err := jw.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []jsonlRecord
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		var record jsonlRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records, buf.String()
+}
+
+func TestJSONLRecords(t *testing.T) {
+	records, _ := streamJSONL(t, RedactNone)
+
+	want := []struct {
+		record, source, category string
+		count                    int
+	}{
+		{"source", "app.js", "", 0},
+		{"finding", "app.js", types.CategorySecrets, 1},
+		{"finding", "app.js", types.CategoryURLs, 1},
+		{"source", "vendor.js", "", 0},
+		{"finding", "vendor.js", types.CategoryURLs, 2},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d: %+v", len(records), len(want), records)
+	}
+
+	for i, w := range want {
+		record := records[i]
+		if record.Record != w.record || record.Category != w.category {
+			t.Errorf("record %d = %s %s, want %s %s", i, record.Record, record.Category, w.record, w.category)
+			continue
+		}
+		switch record.Record {
+		case "source":
+			if record.Source != w.source || record.URL != "https://shop.example.org/"+w.source {
+				t.Errorf("record %d: source %s (%s), want %s", i, record.Source, record.URL, w.source)
+			}
+		case "finding":
+			if len(record.Sources) != 1 || record.Sources[0].Source != w.source {
+				t.Errorf("record %d: sources %+v, want only %s", i, record.Sources, w.source)
+			}
+			if record.Count != w.count {
+				t.Errorf("record %d: count %d, want %d", i, record.Count, w.count)
+			}
+		}
+	}
+
+	if records[0].Findings != 2 || records[3].Findings != 1 {
+		t.Errorf("source finding counts = %d, %d, want 2, 1", records[0].Findings, records[3].Findings)
+	}
+	if records[2].ID != records[4].ID {
+		t.Errorf("the shared URL has ids %s and %s, want one", records[2].ID, records[4].ID)
+	}
+}
+
+func TestJSONLRedaction(t *testing.T) {
+	for _, redaction := range []Redaction{RedactPartial, RedactFull, RedactHash} {
+		records, out := streamJSONL(t, redaction)
+		if strings.Contains(out, "vBqbwq7sdTWx6u") {
+			t.Errorf("-redact %s: JSONL output contains the secret:\n%s", redaction, out)
+		}
+		if len(records) != 5 {
+			t.Errorf("-redact %s: got %d records, want 5", redaction, len(records))
+		}
+	}
+
+	_, out := streamJSONL(t, RedactNone)
+	if !strings.Contains(out, "vBqbwq7sdTWx6u") {
+		t.Error("-redact none hid the secret")
+	}
+}
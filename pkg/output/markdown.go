@@ -0,0 +1,191 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// severityOrder lists severities from most to least severe
+var severityOrder = []string{"critical", "high", "medium", "low", "info"}
+
+// markdownTitles holds the section heading of each category
+var markdownTitles = map[string]string{
+	types.CategoryEndpoints: "API Endpoints",
+	types.CategoryURLs:      "URLs",
+	types.CategorySecrets:   "Secrets",
+	types.CategoryEmails:    "Emails",
+	types.CategoryFiles:     "Files",
+}
+
+// AggregatedToMarkdown converts aggregated findings to a Markdown report for
+// bug bounty submissions: a summary table, secrets by severity with their
+// source locations and snippets, endpoints grouped by host, then URLs,
+// emails and files.
+func AggregatedToMarkdown(af *types.AggregatedFindings, redaction Redaction) string {
+	redact := aggregatedRedactor(af, redaction)
+	var output strings.Builder
+
+	output.WriteString("# jsmap Report\n\n")
+
+	// Summary
+	output.WriteString("## Summary\n\n")
+	output.WriteString(fmt.Sprintf("Analyzed %d sources.\n\n", len(af.Sources)))
+	output.WriteString("| Category | Findings |\n|---|---:|\n")
+	for _, category := range types.Categories {
+		output.WriteString(fmt.Sprintf("| %s | %d |\n", markdownTitles[category], af.Count(category)))
+	}
+	output.WriteString(fmt.Sprintf("| **Total** | **%d** |\n\n", len(af.Findings)))
+
+	bySeverity := make(map[string]int)
+	for _, finding := range af.Findings {
+		bySeverity[finding.Severity]++
+	}
+	output.WriteString("| Severity | Findings |\n|---|---:|\n")
+	for _, severity := range severityOrder {
+		if bySeverity[severity] > 0 {
+			output.WriteString(fmt.Sprintf("| %s | %d |\n", severity, bySeverity[severity]))
+		}
+	}
+	output.WriteString("\n")
+
+	// Secrets by severity
+	if secrets := redactedFindings(af, types.CategorySecrets, redact); len(secrets) > 0 {
+		output.WriteString("## Secrets\n\n")
+		for _, severity := range severityOrder {
+			var items []*types.AggregatedFinding
+			for _, secret := range secrets {
+				if secret.Severity == severity {
+					items = append(items, secret)
+				}
+			}
+			if len(items) == 0 {
+				continue
+			}
+
+			output.WriteString(fmt.Sprintf("### %s (%d)\n\n", strings.ToUpper(severity[:1])+severity[1:], len(items)))
+			for _, secret := range items {
+				output.WriteString(fmt.Sprintf("#### %s: %s\n\n", markdownText(secret.Type), markdownCode(secret.Value)))
+				output.WriteString(fmt.Sprintf("- **Rule:** %s\n", markdownCode(secret.RuleID)))
+				output.WriteString(fmt.Sprintf("- **Confidence:** %s\n", secret.Confidence))
+				output.WriteString("- **Found in:**\n")
+				for _, location := range sourceLocations(secret.Sources) {
+					output.WriteString(fmt.Sprintf("  - %s\n", markdownCode(location)))
+				}
+				output.WriteString("\n")
+				if secret.Location.Snippet != "" {
+					output.WriteString(markdownCodeBlock(secret.Location.Snippet))
+				}
+			}
+		}
+	}
+
+	// Endpoints by host
+	if endpoints := redactedFindings(af, types.CategoryEndpoints, redact); len(endpoints) > 0 {
+		output.WriteString(fmt.Sprintf("## API Endpoints (%d)\n\n", len(endpoints)))
+
+		byHost := make(map[string][]*types.AggregatedFinding)
+		var hosts []string
+		for _, ep := range endpoints {
+			servers, _ := splitEndpoint(ep.Value, ep.Sources)
+			host := servers[0]
+			if _, exists := byHost[host]; !exists {
+				hosts = append(hosts, host)
+			}
+			byHost[host] = append(byHost[host], ep)
+		}
+		sort.Strings(hosts)
+
+		for _, host := range hosts {
+			heading := host
+			if host == "/" {
+				heading = "Relative to the scanned files"
+			}
+			output.WriteString(fmt.Sprintf("### %s\n\n", markdownText(heading)))
+			output.WriteString("| Method | Endpoint | Request | Found in | Context |\n|---|---|---|---|---|\n")
+			for _, ep := range byHost[host] {
+				_, path := splitEndpoint(ep.Value, ep.Sources)
+				output.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+					strings.TrimSpace(methodPrefix(ep.Request)),
+					markdownCode(path),
+					markdownText(requestDetails(ep.Request)),
+					markdownText(strings.Join(sourceLocations(ep.Sources), ", ")),
+					markdownCode(ep.Location.Snippet)))
+			}
+			output.WriteString("\n")
+		}
+	}
+
+	// URLs, emails and files
+	for _, category := range []string{types.CategoryURLs, types.CategoryEmails, types.CategoryFiles} {
+		items := redactedFindings(af, category, redact)
+		if len(items) == 0 {
+			continue
+		}
+		output.WriteString(fmt.Sprintf("## %s (%d)\n\n", markdownTitles[category], len(items)))
+		output.WriteString("| Value | Found in | Context |\n|---|---|---|\n")
+		for _, finding := range items {
+			output.WriteString(fmt.Sprintf("| %s | %s | %s |\n",
+				markdownCode(finding.Value),
+				markdownText(strings.Join(sourceLocations(finding.Sources), ", ")),
+				markdownCode(finding.Location.Snippet)))
+		}
+		output.WriteString("\n")
+	}
+
+	if len(af.Findings) == 0 {
+		output.WriteString("No findings detected.\n")
+	}
+
+	return output.String()
+}
+
+// markdownText escapes Markdown syntax in plain text, including the pipes
+// of table cells
+func markdownText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+		"<", "&lt;", ">", "&gt;", "|", `\|`, "#", `\#`,
+	).Replace(text)
+}
+
+// markdownCode renders text as inline code, safe inside table cells. The
+// backtick fence is longer than any backtick run in the text.
+func markdownCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	text = strings.ReplaceAll(text, "|", `\|`)
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// markdownCodeBlock renders text as a fenced JavaScript code block
+func markdownCodeBlock(text string) string {
+	fence := "```"
+	if run := longestRun(text, '`'); run >= len(fence) {
+		fence = strings.Repeat("`", run+1)
+	}
+	return fence + "js\n" + text + "\n" + fence + "\n\n"
+}
+
+// longestRun returns the length of the longest run of c in text
+func longestRun(text string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != c {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
@@ -0,0 +1,328 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// openAPIDocument is an OpenAPI 3.0 document
+type openAPIDocument struct {
+	OpenAPI string                      `json:"openapi"`
+	Info    openAPIInfo                 `json:"info"`
+	Servers []openAPIServer             `json:"servers,omitempty"`
+	Paths   map[string]*openAPIPathItem `json:"paths"`
+}
+
+// openAPIInfo is the info object of an OpenAPI document
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// openAPIServer is a base URL the paths of an OpenAPI document are relative to
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+// openAPIPathItem holds the operations of a path. Servers is only set when
+// the document has several servers.
+type openAPIPathItem struct {
+	Servers []openAPIServer   `json:"servers,omitempty"`
+	Get     *openAPIOperation `json:"get,omitempty"`
+	Put     *openAPIOperation `json:"put,omitempty"`
+	Post    *openAPIOperation `json:"post,omitempty"`
+	Delete  *openAPIOperation `json:"delete,omitempty"`
+	Options *openAPIOperation `json:"options,omitempty"`
+	Head    *openAPIOperation `json:"head,omitempty"`
+	Patch   *openAPIOperation `json:"patch,omitempty"`
+}
+
+// openAPIOperation is a single method of a path
+type openAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	OperationID string                     `json:"operationId"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+// openAPIParameter is a path, query or header parameter
+type openAPIParameter struct {
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required,omitempty"`
+	Schema   openAPISchema `json:"schema"`
+}
+
+// openAPIRequestBody is the body of an operation
+type openAPIRequestBody struct {
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+// openAPIMediaType is the schema of a body for a content type
+type openAPIMediaType struct {
+	Schema openAPISchema `json:"schema"`
+}
+
+// openAPIResponse is a response of an operation
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
+
+// openAPISchema is the subset of a JSON schema needed for discovered values
+type openAPISchema struct {
+	Type       string                   `json:"type,omitempty"`
+	Properties map[string]openAPISchema `json:"properties,omitempty"`
+}
+
+// openAPIIgnoredHeaders are headers OpenAPI describes elsewhere than in
+// parameters, so they must not be listed as header parameters
+var openAPIIgnoredHeaders = map[string]bool{
+	"accept":        true,
+	"content-type":  true,
+	"authorization": true,
+}
+
+// openAPIEndpoint collects every finding of a path before it is rendered
+type openAPIEndpoint struct {
+	summary string
+	servers []string
+	sources []string
+	request types.Request
+}
+
+// AggregatedToOpenAPI converts the endpoints of aggregated findings to an
+// OpenAPI 3.0 document. Absolute endpoints are grouped under their origin and
+// relative ones under the origin of the bundles they were found in. {name}
+// segments become path parameters, and each operation is tagged with the
+// sources it was found in.
+func AggregatedToOpenAPI(af *types.AggregatedFindings) string {
+	endpoints := make(map[string]*openAPIEndpoint)
+	var allServers []string
+	for _, finding := range af.ByCategory(types.CategoryEndpoints) {
+		servers, path := splitEndpoint(finding.Value, finding.Sources)
+
+		endpoint, exists := endpoints[path]
+		if !exists {
+			endpoint = &openAPIEndpoint{summary: finding.Type}
+			endpoints[path] = endpoint
+		}
+		endpoint.servers = appendUnique(endpoint.servers, servers...)
+		endpoint.sources = appendUnique(endpoint.sources, sourceNames(finding.Sources)...)
+		endpoint.request.Merge(finding.Request)
+		allServers = appendUnique(allServers, servers...)
+	}
+	sort.Strings(allServers)
+
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "jsmap discovered endpoints",
+			Description: fmt.Sprintf("Endpoints extracted from %d JavaScript sources", len(af.Sources)),
+			Version:     "1.0.0",
+		},
+		Servers: openAPIServers(allServers),
+		Paths:   make(map[string]*openAPIPathItem, len(endpoints)),
+	}
+
+	// Visit paths in order so colliding operation IDs get stable suffixes
+	paths := make([]string, 0, len(endpoints))
+	for path := range endpoints {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	usedIDs := make(map[string]bool)
+	for _, path := range paths {
+		endpoint := endpoints[path]
+		item := &openAPIPathItem{}
+		if len(allServers) > 1 {
+			sort.Strings(endpoint.servers)
+			item.Servers = openAPIServers(endpoint.servers)
+		}
+
+		methods := endpoint.request.Methods
+		if len(methods) == 0 {
+			methods = []string{"GET"}
+		}
+		for _, method := range methods {
+			op := newOpenAPIOperation(method, path, endpoint)
+			op.OperationID = uniqueOperationID(op.OperationID, usedIDs)
+			item.set(method, op)
+		}
+		doc.Paths[path] = item
+	}
+
+	jsonBytes, _ := json.MarshalIndent(doc, "", "  ")
+	return string(jsonBytes)
+}
+
+// newOpenAPIOperation builds the operation of a method on a path
+func newOpenAPIOperation(method, path string, endpoint *openAPIEndpoint) *openAPIOperation {
+	op := &openAPIOperation{
+		Summary:     endpoint.summary,
+		OperationID: operationID(method, path),
+		Tags:        endpoint.sources,
+		Responses:   map[string]openAPIResponse{"default": {Description: "Unknown response"}},
+	}
+
+	for _, name := range pathParams(path) {
+		op.Parameters = append(op.Parameters, openAPIParameter{Name: name, In: "path", Required: true, Schema: openAPISchema{Type: "string"}})
+	}
+	for _, name := range endpoint.request.Query {
+		op.Parameters = append(op.Parameters, openAPIParameter{Name: name, In: "query", Schema: openAPISchema{Type: "string"}})
+	}
+	for _, name := range endpoint.request.Headers {
+		if !openAPIIgnoredHeaders[strings.ToLower(name)] {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: name, In: "header", Schema: openAPISchema{Type: "string"}})
+		}
+	}
+
+	if len(endpoint.request.Body) > 0 && bodyMethods[method] {
+		schema := openAPISchema{Type: "object", Properties: make(map[string]openAPISchema)}
+		for _, key := range endpoint.request.Body {
+			schema.Properties[key] = openAPISchema{}
+		}
+		op.RequestBody = &openAPIRequestBody{
+			Content: map[string]openAPIMediaType{"application/json": {Schema: schema}},
+		}
+	}
+	return op
+}
+
+// bodyMethods are the methods whose operations get a request body
+var bodyMethods = map[string]bool{
+	"POST":  true,
+	"PUT":   true,
+	"PATCH": true,
+}
+
+// set stores the operation of a method
+func (item *openAPIPathItem) set(method string, op *openAPIOperation) {
+	switch method {
+	case "GET":
+		item.Get = op
+	case "PUT":
+		item.Put = op
+	case "POST":
+		item.Post = op
+	case "DELETE":
+		item.Delete = op
+	case "OPTIONS":
+		item.Options = op
+	case "HEAD":
+		item.Head = op
+	case "PATCH":
+		item.Patch = op
+	}
+}
+
+// splitEndpoint splits an endpoint into the servers it is served from and
+// its path. An absolute URL is split at its path; a relative endpoint is
+// served from the origin of each source URL, or from "/" for local files.
+func splitEndpoint(value string, sources []types.SourceFinding) ([]string, string) {
+	if scheme := strings.Index(value, "://"); scheme >= 0 {
+		rest := value[scheme+3:]
+		slash := strings.IndexByte(rest, '/')
+		if slash < 0 {
+			return []string{value}, "/"
+		}
+		return []string{value[:scheme+3+slash]}, rest[slash:]
+	}
+
+	var servers []string
+	for _, src := range sources {
+		u, err := url.Parse(src.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			continue
+		}
+		servers = appendUnique(servers, u.Scheme+"://"+u.Host)
+	}
+	if len(servers) == 0 {
+		servers = []string{"/"}
+	}
+	return servers, value
+}
+
+// openAPIServers converts base URLs to server objects
+func openAPIServers(urls []string) []openAPIServer {
+	servers := make([]openAPIServer, len(urls))
+	for i, u := range urls {
+		servers[i] = openAPIServer{URL: u}
+	}
+	return servers
+}
+
+// pathParams returns the {name} placeholders of a path in order
+func pathParams(path string) []string {
+	var params []string
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			return params
+		}
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			return params
+		}
+		params = appendUnique(params, path[start+1:start+end])
+		path = path[start+end+1:]
+	}
+}
+
+// operationID derives an operation ID from a method and path, e.g.
+// get_api_users_id for GET /api/users/{id}. Paths differing only in
+// punctuation share an ID, see uniqueOperationID.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	separate := true
+	for _, c := range path {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			if separate {
+				b.WriteByte('_')
+				separate = false
+			}
+			b.WriteRune(c)
+			continue
+		}
+		separate = true
+	}
+	return b.String()
+}
+
+// uniqueOperationID returns id, or id with the first free numeric suffix if
+// it is already in used, and records the result in used
+func uniqueOperationID(id string, used map[string]bool) string {
+	unique := id
+	for n := 2; used[unique]; n++ {
+		unique = id + "_" + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// appendUnique appends the values not already in list
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// openAPIOf renders endpoints found in sources, keyed by source URL, and
+// parses the document
+func openAPIOf(t *testing.T, sources map[string][]types.Finding) openAPIDocument {
+	t.Helper()
+	af := types.NewAggregatedFindings()
+	for url, endpoints := range sources {
+		findings := types.NewFindings(false)
+		for _, finding := range endpoints {
+			finding.Category = types.CategoryEndpoints
+			finding.Type = "API Endpoint"
+			finding.Location = types.Location{Line: 1, Column: 1}
+			findings.Add(finding)
+		}
+		af.AddFindings(findings, url, url, 200)
+	}
+
+	var doc openAPIDocument
+	if err := json.Unmarshal([]byte(AggregatedToOpenAPI(af)), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestOpenAPIParameters(t *testing.T) {
+	doc := openAPIOf(t, map[string][]types.Finding{
+		"https://shop.example.org/main.js": {{
+			Value: "/api/users/{id}/orders/{orderId}",
+			Request: &types.Request{
+				Methods: []string{"GET", "POST"},
+				Query:   []string{"page"},
+				Headers: []string{"X-Tenant", "Content-Type"},
+				Body:    []string{"note"},
+			},
+		}},
+	})
+
+	item := doc.Paths["/api/users/{id}/orders/{orderId}"]
+	if item == nil || item.Get == nil || item.Post == nil {
+		t.Fatalf("paths = %+v, want GET and POST on the endpoint", doc.Paths)
+	}
+
+	want := []openAPIParameter{
+		{Name: "id", In: "path", Required: true, Schema: openAPISchema{Type: "string"}},
+		{Name: "orderId", In: "path", Required: true, Schema: openAPISchema{Type: "string"}},
+		{Name: "page", In: "query", Schema: openAPISchema{Type: "string"}},
+		{Name: "X-Tenant", In: "header", Schema: openAPISchema{Type: "string"}},
+	}
+	if !reflect.DeepEqual(item.Get.Parameters, want) {
+		t.Errorf("parameters = %+v, want %+v", item.Get.Parameters, want)
+	}
+	if item.Get.RequestBody != nil {
+		t.Errorf("GET has a request body")
+	}
+	if item.Post.RequestBody == nil {
+		t.Fatalf("POST has no request body")
+	}
+	if _, ok := item.Post.RequestBody.Content["application/json"].Schema.Properties["note"]; !ok {
+		t.Errorf("request body = %+v, want the note property", item.Post.RequestBody)
+	}
+}
+
+func TestOpenAPIServers(t *testing.T) {
+	doc := openAPIOf(t, map[string][]types.Finding{
+		"https://shop.example.org/main.js": {
+			{Value: "/api/cart"},
+			{Value: "https://payments.example.net/v1/charge"},
+		},
+		"https://admin.example.org/admin.js": {
+			{Value: "/api/cart"},
+		},
+	})
+
+	wantServers := []openAPIServer{
+		{URL: "https://admin.example.org"},
+		{URL: "https://payments.example.net"},
+		{URL: "https://shop.example.org"},
+	}
+	if !reflect.DeepEqual(doc.Servers, wantServers) {
+		t.Errorf("servers = %+v, want %+v", doc.Servers, wantServers)
+	}
+
+	tests := map[string][]openAPIServer{
+		"/api/cart":  {{URL: "https://admin.example.org"}, {URL: "https://shop.example.org"}},
+		"/v1/charge": {{URL: "https://payments.example.net"}},
+	}
+	for path, want := range tests {
+		item := doc.Paths[path]
+		if item == nil {
+			t.Errorf("path %s missing", path)
+			continue
+		}
+		if !reflect.DeepEqual(item.Servers, want) {
+			t.Errorf("%s: servers = %+v, want %+v", path, item.Servers, want)
+		}
+	}
+}
+
+func TestOpenAPIOperationIDsAreUnique(t *testing.T) {
+	doc := openAPIOf(t, map[string][]types.Finding{
+		"app.js": {
+			{Value: "/api/users-list"},
+			{Value: "/api/users_list"},
+			{Value: "/api/users/list"},
+			{Value: "/api/users/{list}", Request: &types.Request{Methods: []string{"GET", "DELETE"}}},
+		},
+	})
+
+	seen := make(map[string]string)
+	for path, item := range doc.Paths {
+		for _, op := range []*openAPIOperation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch} {
+			if op == nil {
+				continue
+			}
+			if previous, exists := seen[op.OperationID]; exists {
+				t.Errorf("operationId %q used by %s and %s", op.OperationID, previous, path)
+			}
+			seen[op.OperationID] = path
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("got %d operations, want 5: %v", len(seen), seen)
+	}
+	if seen["get_api_users_list"] != "/api/users-list" || seen["get_api_users_list_2"] != "/api/users/list" {
+		t.Errorf("operationIds = %v, want suffixes assigned in path order", seen)
+	}
+}
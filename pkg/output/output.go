@@ -4,14 +4,43 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"html"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/0xhkx0/jsmap/pkg/types"
 )
 
+// categoryTitles holds the table heading of each category
+var categoryTitles = map[string]string{
+	types.CategoryEndpoints: "📍 API ENDPOINTS",
+	types.CategoryURLs:      "🌐 URLS",
+	types.CategorySecrets:   "🔐 SECRETS",
+	types.CategoryEmails:    "📧 EMAILS",
+	types.CategoryFiles:     "📄 FILES",
+}
+
+// categoryLabels holds the singular label of each category used in CSV rows
+var categoryLabels = map[string]string{
+	types.CategoryEndpoints: "endpoint",
+	types.CategoryURLs:      "url",
+	types.CategorySecrets:   "secret",
+	types.CategoryEmails:    "email",
+	types.CategoryFiles:     "file",
+}
+
+// aggregatedCSVHeader is the header of aggregated CSV output. Columns are
+// only ever appended, so imports keyed on names or positions keep working.
+var aggregatedCSVHeader = []string{
+	"id", "category", "type", "rule_id", "value", "severity", "confidence",
+	"source", "url", "status_code", "line", "column", "count", "snippet",
+	"original",
+}
+
 // ToTable converts findings to ASCII table format
-func ToTable(findings *types.Findings) string {
+func ToTable(findings *types.Findings, redaction Redaction) string {
+	redact := newRedactor(redaction, findings.ByCategory(types.CategorySecrets))
 	var output strings.Builder
 
 	output.WriteString("\n")
@@ -19,62 +48,25 @@ func ToTable(findings *types.Findings) string {
 	output.WriteString("║                         JS ANALYSIS RESULTS                       ║\n")
 	output.WriteString("╚═══════════════════════════════════════════════════════════════════╝\n\n")
 
-	// Endpoints
-	if len(findings.Endpoints) > 0 {
-		output.WriteString("📍 ENDPOINTS (" + fmt.Sprintf("%d", len(findings.Endpoints)) + ")\n")
-		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		endpoints := sortedKeys(findings.Endpoints)
-		for _, ep := range endpoints {
-			output.WriteString(fmt.Sprintf("  • %s\n", ep))
-		}
-		output.WriteString("\n")
-	}
-
-	// URLs
-	if len(findings.URLs) > 0 {
-		output.WriteString("🌐 URLs (" + fmt.Sprintf("%d", len(findings.URLs)) + ")\n")
-		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		urls := sortedKeys(findings.URLs)
-		for _, url := range urls {
-			output.WriteString(fmt.Sprintf("  • %s\n", url))
+	for _, category := range types.Categories {
+		items := sortedFindings(findings, category, redact)
+		if len(items) == 0 {
+			continue
 		}
-		output.WriteString("\n")
-	}
-
-	// Secrets
-	if len(findings.Secrets) > 0 {
-		output.WriteString("🔐 SECRETS (" + fmt.Sprintf("%d", len(findings.Secrets)) + ")\n")
-		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		for _, secret := range findings.Secrets {
-			output.WriteString(fmt.Sprintf("  ⚠️  %s\n", secret.Value))
-		}
-		output.WriteString("\n")
-	}
 
-	// Emails
-	if len(findings.Emails) > 0 {
-		output.WriteString("📧 EMAILS (" + fmt.Sprintf("%d", len(findings.Emails)) + ")\n")
+		output.WriteString(categoryTitles[category] + " (" + fmt.Sprintf("%d", len(items)) + ")\n")
 		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		emails := sortedKeys(findings.Emails)
-		for _, email := range emails {
-			output.WriteString(fmt.Sprintf("  • %s\n", email))
-		}
-		output.WriteString("\n")
-	}
-
-	// Files
-	if len(findings.Files) > 0 {
-		output.WriteString("📄 FILES (" + fmt.Sprintf("%d", len(findings.Files)) + ")\n")
-		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		files := sortedKeys(findings.Files)
-		for _, file := range files {
-			output.WriteString(fmt.Sprintf("  • %s\n", file))
+		for _, finding := range items {
+			if category == types.CategorySecrets {
+				output.WriteString(fmt.Sprintf("  ⚠️  %s (%s, %s)%s\n", finding.Value, finding.Type, finding.Severity, locationSuffix(finding.Location)))
+			} else {
+				output.WriteString(fmt.Sprintf("  • %s%s%s\n", methodPrefix(finding.Request), finding.Value, locationSuffix(finding.Location)))
+			}
 		}
 		output.WriteString("\n")
 	}
 
-	totalCount := len(findings.Endpoints) + len(findings.URLs) + len(findings.Secrets) + len(findings.Emails) + len(findings.Files)
-	if totalCount == 0 {
+	if len(findings.Items) == 0 {
 		output.WriteString("No findings detected.\n\n")
 	}
 
@@ -84,21 +76,23 @@ func ToTable(findings *types.Findings) string {
 }
 
 // ToJSON converts findings to JSON format
-func ToJSON(findings *types.Findings) string {
+func ToJSON(findings *types.Findings, redaction Redaction) string {
+	redact := newRedactor(redaction, findings.ByCategory(types.CategorySecrets))
+	items := make([]jsonFinding, 0, len(findings.Items))
+	for _, category := range types.Categories {
+		for _, finding := range sortedFindings(findings, category, redact) {
+			items = append(items, newJSONFinding(finding, nil))
+		}
+	}
+
+	summary := map[string]int{"total": len(findings.Items)}
+	for _, category := range types.Categories {
+		summary[category] = len(findings.ByCategory(category))
+	}
+
 	data := map[string]interface{}{
-		"endpoints": sortedKeys(findings.Endpoints),
-		"urls":      sortedKeys(findings.URLs),
-		"emails":    sortedKeys(findings.Emails),
-		"files":     sortedKeys(findings.Files),
-		"secrets":   findings.Secrets,
-		"summary": map[string]int{
-			"endpoints": len(findings.Endpoints),
-			"urls":      len(findings.URLs),
-			"secrets":   len(findings.Secrets),
-			"emails":    len(findings.Emails),
-			"files":     len(findings.Files),
-			"total": len(findings.Endpoints) + len(findings.URLs) + len(findings.Secrets) + len(findings.Emails) + len(findings.Files),
-		},
+		"findings": items,
+		"summary":  summary,
 	}
 
 	jsonBytes, _ := json.MarshalIndent(data, "", "  ")
@@ -106,36 +100,25 @@ func ToJSON(findings *types.Findings) string {
 }
 
 // ToCSV converts findings to CSV format
-func ToCSV(findings *types.Findings) string {
+func ToCSV(findings *types.Findings, redaction Redaction) string {
+	redact := newRedactor(redaction, findings.ByCategory(types.CategorySecrets))
 	var output strings.Builder
 	w := csv.NewWriter(&output)
 
 	// Header
-	w.Write([]string{"Category", "Value", "Source"})
-
-	// Endpoints
-	for _, ep := range sortedKeys(findings.Endpoints) {
-		w.Write([]string{"endpoint", ep, ""})
-	}
-
-	// URLs
-	for _, url := range sortedKeys(findings.URLs) {
-		w.Write([]string{"url", url, ""})
-	}
-
-	// Emails
-	for _, email := range sortedKeys(findings.Emails) {
-		w.Write([]string{"email", email, ""})
-	}
-
-	// Files
-	for _, file := range sortedKeys(findings.Files) {
-		w.Write([]string{"file", file, ""})
-	}
-
-	// Secrets
-	for _, secret := range findings.Secrets {
-		w.Write([]string{"secret", secret.Value, secret.Source})
+	w.Write([]string{"Category", "Type", "Value", "Severity", "Confidence", "Source", "Line", "Column", "Snippet"})
+
+	for _, category := range types.Categories {
+		for _, finding := range sortedFindings(findings, category, redact) {
+			w.Write(append([]string{
+				categoryLabels[category],
+				finding.Type,
+				finding.Value,
+				finding.Severity,
+				finding.Confidence,
+				finding.Source,
+			}, locationColumns(finding.Location)...))
+		}
 	}
 
 	w.Flush()
@@ -143,7 +126,8 @@ func ToCSV(findings *types.Findings) string {
 }
 
 // AggregatedToTable converts aggregated findings to ASCII table format
-func AggregatedToTable(af *types.AggregatedFindings) string {
+func AggregatedToTable(af *types.AggregatedFindings, redaction Redaction) string {
+	redact := aggregatedRedactor(af, redaction)
 	var output strings.Builder
 
 	output.WriteString("\n")
@@ -155,100 +139,49 @@ func AggregatedToTable(af *types.AggregatedFindings) string {
 	if len(af.Sources) > 0 {
 		output.WriteString("📊 SOURCES (" + fmt.Sprintf("%d", len(af.Sources)) + ")\n")
 		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		sources := make([]string, 0, len(af.Sources))
-		for s := range af.Sources {
-			sources = append(sources, s)
-		}
-		sort.Strings(sources)
-		for _, s := range sources {
-			sf := af.Sources[s]
-			output.WriteString(fmt.Sprintf("  • %s (Status: %d)\n", s, sf.StatusCode))
+		for _, sf := range af.SortedSources() {
+			output.WriteString(fmt.Sprintf("  • %s (Status: %d, Findings: %d)\n", sf.Source, sf.StatusCode, sf.Count))
 		}
 		output.WriteString("\n")
 	}
 
-	// Endpoints
-	if len(af.Endpoints) > 0 {
-		output.WriteString("📍 API ENDPOINTS (" + fmt.Sprintf("%d", len(af.Endpoints)) + ")\n")
-		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		endpoints := make([]string, 0, len(af.Endpoints))
-		for ep := range af.Endpoints {
-			endpoints = append(endpoints, ep)
+	for _, category := range types.Categories {
+		items := redactedFindings(af, category, redact)
+		if len(items) == 0 {
+			continue
 		}
-		sort.Strings(endpoints)
-		for _, ep := range endpoints {
-			sources := af.Endpoints[ep]
-			if len(sources) == 1 {
-				output.WriteString(fmt.Sprintf("  • %s\n", ep))
-			} else {
-				output.WriteString(fmt.Sprintf("  • %s [%d sources]\n", ep, len(sources)))
-			}
-		}
-		output.WriteString("\n")
-	}
 
-	// URLs
-	if len(af.URLs) > 0 {
-		output.WriteString("🌐 URLS (" + fmt.Sprintf("%d", len(af.URLs)) + ")\n")
-		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		urls := make([]string, 0, len(af.URLs))
-		for u := range af.URLs {
-			urls = append(urls, u)
-		}
-		sort.Strings(urls)
-		for _, u := range urls {
-			if len(u) > 60 {
-				output.WriteString(fmt.Sprintf("  • %s...\n", u[:60]))
-			} else {
-				output.WriteString(fmt.Sprintf("  • %s\n", u))
-			}
+		heading := categoryTitles[category] + " (" + fmt.Sprintf("%d", len(items)) + ")"
+		if category == types.CategorySecrets {
+			heading += " ⚠️  HIGH PRIORITY"
 		}
-		output.WriteString("\n")
-	}
-
-	// Secrets
-	if len(af.Secrets) > 0 {
-		output.WriteString("🔐 SECRETS (" + fmt.Sprintf("%d", len(af.Secrets)) + ") ⚠️  HIGH PRIORITY\n")
+		output.WriteString(heading + "\n")
 		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		for _, secret := range af.Secrets {
-			output.WriteString(fmt.Sprintf("  ⚠️  %s\n", secret.Value))
-			output.WriteString(fmt.Sprintf("      └─ Source: %s\n", secret.Source))
-		}
-		output.WriteString("\n")
-	}
 
-	// Emails
-	if len(af.Emails) > 0 {
-		output.WriteString("📧 EMAILS (" + fmt.Sprintf("%d", len(af.Emails)) + ")\n")
-		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		emails := make([]string, 0, len(af.Emails))
-		for email := range af.Emails {
-			emails = append(emails, email)
-		}
-		sort.Strings(emails)
-		for _, email := range emails {
-			output.WriteString(fmt.Sprintf("  • %s\n", email))
-		}
-		output.WriteString("\n")
-	}
-
-	// Files
-	if len(af.Files) > 0 {
-		output.WriteString("📄 FILES (" + fmt.Sprintf("%d", len(af.Files)) + ")\n")
-		output.WriteString("────────────────────────────────────────────────────────────────\n")
-		files := make([]string, 0, len(af.Files))
-		for file := range af.Files {
-			files = append(files, file)
-		}
-		sort.Strings(files)
-		for _, file := range files {
-			output.WriteString(fmt.Sprintf("  • %s\n", file))
+		for _, finding := range items {
+			switch category {
+			case types.CategorySecrets:
+				output.WriteString(fmt.Sprintf("  ⚠️  %s (%s)\n", finding.Value, finding.Type))
+				output.WriteString(fmt.Sprintf("      ├─ Severity: %s, Confidence: %s\n", finding.Severity, finding.Confidence))
+				output.WriteString(fmt.Sprintf("      └─ Source: %s\n", strings.Join(sourceLocations(finding.Sources), ", ")))
+			case types.CategoryURLs:
+				if len(finding.Value) > 60 {
+					output.WriteString(fmt.Sprintf("  • %s...\n", finding.Value[:60]))
+				} else {
+					output.WriteString(fmt.Sprintf("  • %s\n", finding.Value))
+				}
+			default:
+				if len(finding.Sources) == 1 {
+					output.WriteString(fmt.Sprintf("  • %s%s%s\n", methodPrefix(finding.Request), finding.Value, locationSuffix(finding.Sources[0].Location)))
+				} else {
+					output.WriteString(fmt.Sprintf("  • %s%s [%d sources]\n", methodPrefix(finding.Request), finding.Value, len(finding.Sources)))
+				}
+			}
 		}
 		output.WriteString("\n")
 	}
 
-	totalCount := len(af.Endpoints) + len(af.URLs) + len(af.Secrets) + len(af.Emails) + len(af.Files)
-	if totalCount == 0 {
+	if len(af.Findings) == 0 {
 		output.WriteString("No findings detected.\n\n")
 	}
 
@@ -257,163 +190,148 @@ func AggregatedToTable(af *types.AggregatedFindings) string {
 	return output.String()
 }
 
-// AggregatedToJSON converts aggregated findings to JSON format
-func AggregatedToJSON(af *types.AggregatedFindings) string {
-	data := map[string]interface{}{
-		"sources": func() []map[string]interface{} {
-			var result []map[string]interface{}
-			for _, src := range af.Sources {
-				result = append(result, map[string]interface{}{
-					"name":        src.Source,
-					"url":         src.URL,
-					"status_code": src.StatusCode,
-				})
-			}
-			return result
-		}(),
-		"endpoints": func() map[string]interface{} {
-			result := make(map[string]interface{})
-			for ep, sources := range af.Endpoints {
-				sourceNames := make([]string, len(sources))
-				for i, src := range sources {
-					sourceNames[i] = src.Source
-				}
-				result[ep] = map[string]interface{}{
-					"count":   len(sources),
-					"sources": sourceNames,
-				}
-			}
-			return result
-		}(),
-		"urls": func() map[string]interface{} {
-			result := make(map[string]interface{})
-			for u, sources := range af.URLs {
-				sourceNames := make([]string, len(sources))
-				for i, src := range sources {
-					sourceNames[i] = src.Source
-				}
-				result[u] = map[string]interface{}{
-					"count":   len(sources),
-					"sources": sourceNames,
-				}
-			}
-			return result
-		}(),
-		"emails": func() map[string]interface{} {
-			result := make(map[string]interface{})
-			for email, sources := range af.Emails {
-				sourceNames := make([]string, len(sources))
-				for i, src := range sources {
-					sourceNames[i] = src.Source
-				}
-				result[email] = map[string]interface{}{
-					"count":   len(sources),
-					"sources": sourceNames,
-				}
-			}
-			return result
-		}(),
-		"files": func() map[string]interface{} {
-			result := make(map[string]interface{})
-			for file, sources := range af.Files {
-				sourceNames := make([]string, len(sources))
-				for i, src := range sources {
-					sourceNames[i] = src.Source
-				}
-				result[file] = map[string]interface{}{
-					"count":   len(sources),
-					"sources": sourceNames,
-				}
-			}
-			return result
-		}(),
-		"secrets": af.Secrets,
-		"summary": map[string]interface{}{
-			"endpoints": len(af.Endpoints),
-			"urls":      len(af.URLs),
-			"secrets":   len(af.Secrets),
-			"emails":    len(af.Emails),
-			"files":     len(af.Files),
-			"total":     len(af.Endpoints) + len(af.URLs) + len(af.Secrets) + len(af.Emails) + len(af.Files),
-			"sources":   len(af.Sources),
-		},
-	}
-
-	jsonBytes, _ := json.MarshalIndent(data, "", "  ")
-	return string(jsonBytes)
+// jsonSource is a source of a finding in JSON output
+type jsonSource struct {
+	Source     string         `json:"source"`
+	URL        string         `json:"url,omitempty"`
+	StatusCode int            `json:"status_code,omitempty"`
+	Count      int            `json:"count"`
+	Location   types.Location `json:"location"`
 }
 
-// AggregatedToCSV converts aggregated findings to CSV format
-func AggregatedToCSV(af *types.AggregatedFindings) string {
-	var output strings.Builder
-	w := csv.NewWriter(&output)
-
-	// Header
-	w.Write([]string{"Category", "Value", "Sources", "Count"})
+// jsonFinding is a finding in JSON output
+type jsonFinding struct {
+	ID          string         `json:"id"`
+	Category    string         `json:"category"`
+	Type        string         `json:"type"`
+	RuleID      string         `json:"rule_id"`
+	Value       string         `json:"value"`
+	Severity    string         `json:"severity"`
+	Confidence  string         `json:"confidence"`
+	Location    types.Location `json:"location"`
+	Tags        []string       `json:"tags,omitempty"`
+	Params      []string       `json:"params,omitempty"`
+	Request     *types.Request `json:"request,omitempty"`
+	Occurrences int            `json:"occurrences"`
+	Count       int            `json:"count"`
+	Sources     []jsonSource   `json:"sources"`
+}
 
-	// Endpoints
-	endpoints := make([]string, 0, len(af.Endpoints))
-	for ep := range af.Endpoints {
-		endpoints = append(endpoints, ep)
-	}
-	sort.Strings(endpoints)
-	for _, ep := range endpoints {
-		sources := af.Endpoints[ep]
-		sourceNames := make([]string, len(sources))
-		for i, src := range sources {
-			sourceNames[i] = src.Source
+// newJSONFinding builds the JSON form of a finding. Without aggregated
+// sources the finding's own source and location are used.
+func newJSONFinding(finding types.Finding, sources []types.SourceFinding) jsonFinding {
+	if sources == nil {
+		sources = []types.SourceFinding{{Source: finding.Source, Count: finding.Occurrences, Location: finding.Location}}
+	}
+
+	jf := jsonFinding{
+		ID:          finding.ID,
+		Category:    finding.Category,
+		Type:        finding.Type,
+		RuleID:      finding.RuleID,
+		Value:       finding.Value,
+		Severity:    finding.Severity,
+		Confidence:  finding.Confidence,
+		Location:    finding.Location,
+		Tags:        finding.Tags,
+		Params:      finding.Params,
+		Request:     finding.Request,
+		Occurrences: finding.Occurrences,
+		Count:       len(sources),
+		Sources:     make([]jsonSource, len(sources)),
+	}
+	for i, src := range sources {
+		jf.Sources[i] = jsonSource{
+			Source:     src.Source,
+			URL:        src.URL,
+			StatusCode: src.StatusCode,
+			Count:      src.Count,
+			Location:   src.Location,
 		}
-		w.Write([]string{"endpoint", ep, strings.Join(sourceNames, ";"), fmt.Sprintf("%d", len(sources))})
 	}
 
-	// URLs
-	urls := make([]string, 0, len(af.URLs))
-	for u := range af.URLs {
-		urls = append(urls, u)
-	}
-	sort.Strings(urls)
-	for _, u := range urls {
-		sources := af.URLs[u]
-		sourceNames := make([]string, len(sources))
-		for i, src := range sources {
-			sourceNames[i] = src.Source
+	return jf
+}
+
+// AggregatedToJSON converts aggregated findings to JSON format
+func AggregatedToJSON(af *types.AggregatedFindings, redaction Redaction) string {
+	redact := aggregatedRedactor(af, redaction)
+	sources := []map[string]interface{}{}
+	for _, src := range af.SortedSources() {
+		sources = append(sources, map[string]interface{}{
+			"name":        src.Source,
+			"url":         src.URL,
+			"status_code": src.StatusCode,
+			"findings":    src.Count,
+		})
+	}
+
+	findings := make([]jsonFinding, 0, len(af.Findings))
+	bySeverity := make(map[string]int)
+	for _, category := range types.Categories {
+		for _, finding := range redactedFindings(af, category, redact) {
+			findings = append(findings, newJSONFinding(finding.Finding, finding.Sources))
+			bySeverity[finding.Severity]++
 		}
-		w.Write([]string{"url", u, strings.Join(sourceNames, ";"), fmt.Sprintf("%d", len(sources))})
 	}
 
-	// Emails
-	emails := make([]string, 0, len(af.Emails))
-	for email := range af.Emails {
-		emails = append(emails, email)
+	summary := map[string]interface{}{
+		"total":       len(af.Findings),
+		"sources":     len(af.Sources),
+		"by_severity": bySeverity,
 	}
-	sort.Strings(emails)
-	for _, email := range emails {
-		sources := af.Emails[email]
-		sourceNames := make([]string, len(sources))
-		for i, src := range sources {
-			sourceNames[i] = src.Source
-		}
-		w.Write([]string{"email", email, strings.Join(sourceNames, ";"), fmt.Sprintf("%d", len(sources))})
+	for _, category := range types.Categories {
+		summary[category] = af.Count(category)
 	}
 
-	// Files
-	files := make([]string, 0, len(af.Files))
-	for file := range af.Files {
-		files = append(files, file)
-	}
-	sort.Strings(files)
-	for _, file := range files {
-		sources := af.Files[file]
-		sourceNames := make([]string, len(sources))
-		for i, src := range sources {
-			sourceNames[i] = src.Source
-		}
-		w.Write([]string{"file", file, strings.Join(sourceNames, ";"), fmt.Sprintf("%d", len(sources))})
+	data := map[string]interface{}{
+		"sources":  sources,
+		"findings": findings,
+		"summary":  summary,
 	}
 
-	// Secrets
-	for _, secret := range af.Secrets {
-		w.Write([]string{"secret", secret.Value, secret.Source, "1"})
+	jsonBytes, _ := json.MarshalIndent(data, "", "  ")
+	return string(jsonBytes)
+}
+
+// AggregatedToCSV converts aggregated findings to CSV format
+func AggregatedToCSV(af *types.AggregatedFindings, redaction Redaction) string {
+	redact := aggregatedRedactor(af, redaction)
+	var output strings.Builder
+	w := csv.NewWriter(&output)
+
+	w.Write(aggregatedCSVHeader)
+	for _, category := range types.Categories {
+		for _, finding := range redactedFindings(af, category, redact) {
+			for _, src := range finding.Sources {
+				line, column := "", ""
+				if src.Location.Line > 0 {
+					line = strconv.Itoa(src.Location.Line)
+					column = strconv.Itoa(src.Location.Column)
+				}
+				status := ""
+				if src.StatusCode > 0 {
+					status = strconv.Itoa(src.StatusCode)
+				}
+				w.Write([]string{
+					finding.ID,
+					categoryLabels[category],
+					finding.Type,
+					finding.RuleID,
+					finding.Value,
+					finding.Severity,
+					finding.Confidence,
+					src.Source,
+					src.URL,
+					status,
+					line,
+					column,
+					strconv.Itoa(src.Count),
+					src.Location.Snippet,
+					originalLocation(src.Location.Original),
+				})
+			}
+		}
 	}
 
 	w.Flush()
@@ -421,7 +339,8 @@ func AggregatedToCSV(af *types.AggregatedFindings) string {
 }
 
 // AggregatedToHTML converts aggregated findings to HTML format
-func AggregatedToHTML(af *types.AggregatedFindings) string {
+func AggregatedToHTML(af *types.AggregatedFindings, redaction Redaction) string {
+	redact := aggregatedRedactor(af, redaction)
 	var output strings.Builder
 
 	output.WriteString(`<!DOCTYPE html>
@@ -444,6 +363,7 @@ func AggregatedToHTML(af *types.AggregatedFindings) string {
         .stat-box { background: #f5f5f5; padding: 15px; border-radius: 5px; text-align: center; }
         .stat-number { font-size: 24px; font-weight: bold; color: #0066cc; }
         .stat-label { color: #666; margin-top: 5px; }
+        code { font-size: 12px; color: #555; word-break: break-all; }
     </style>
 </head>
 <body>
@@ -451,132 +371,195 @@ func AggregatedToHTML(af *types.AggregatedFindings) string {
         <h1>🔍 jsmap - Bug Bounty JavaScript Scanner Report</h1>
 `)
 
-	totalCount := len(af.Endpoints) + len(af.URLs) + len(af.Secrets) + len(af.Emails) + len(af.Files)
-	
 	// Summary statistics
 	output.WriteString(`<div class="summary">`)
-	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number">%d</div><div class="stat-label">Endpoints</div></div>`, len(af.Endpoints)))
-	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number">%d</div><div class="stat-label">URLs</div></div>`, len(af.URLs)))
-	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number"><span class="high">%d</span></div><div class="stat-label">Secrets</div></div>`, len(af.Secrets)))
-	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number">%d</div><div class="stat-label">Emails</div></div>`, len(af.Emails)))
-	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number">%d</div><div class="stat-label">Files</div></div>`, len(af.Files)))
+	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number">%d</div><div class="stat-label">Endpoints</div></div>`, af.Count(types.CategoryEndpoints)))
+	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number">%d</div><div class="stat-label">URLs</div></div>`, af.Count(types.CategoryURLs)))
+	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number"><span class="high">%d</span></div><div class="stat-label">Secrets</div></div>`, af.Count(types.CategorySecrets)))
+	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number">%d</div><div class="stat-label">Emails</div></div>`, af.Count(types.CategoryEmails)))
+	output.WriteString(fmt.Sprintf(`<div class="stat-box"><div class="stat-number">%d</div><div class="stat-label">Files</div></div>`, af.Count(types.CategoryFiles)))
 	output.WriteString(`</div>`)
 
 	// Sources
 	if len(af.Sources) > 0 {
-		output.WriteString(`<h2>📊 Sources</h2><table><tr><th>Source</th><th>URL</th><th>Status</th></tr>`)
-		sources := make([]string, 0, len(af.Sources))
-		for s := range af.Sources {
-			sources = append(sources, s)
-		}
-		sort.Strings(sources)
-		for _, s := range sources {
-			sf := af.Sources[s]
-			output.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td><td>%d</td></tr>`, sf.Source, sf.URL, sf.StatusCode))
+		output.WriteString(`<h2>📊 Sources</h2><table><tr><th>Source</th><th>URL</th><th>Status</th><th>Findings</th></tr>`)
+		for _, sf := range af.SortedSources() {
+			output.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td><td>%d</td><td>%d</td></tr>`, html.EscapeString(sf.Source), html.EscapeString(sf.URL), sf.StatusCode, sf.Count))
 		}
 		output.WriteString(`</table>`)
 	}
 
 	// Secrets
-	if len(af.Secrets) > 0 {
-		output.WriteString(`<h2 class="high">🔐 Secrets (HIGH PRIORITY)</h2><table><tr><th>Secret</th><th>Type</th><th>Source</th></tr>`)
-		for _, secret := range af.Secrets {
-			output.WriteString(fmt.Sprintf(`<tr><td class="high">%s</td><td>%s</td><td>%s</td></tr>`, secret.Value, secret.Type, secret.Source))
+	if secrets := redactedFindings(af, types.CategorySecrets, redact); len(secrets) > 0 {
+		output.WriteString(`<h2 class="high">🔐 Secrets (HIGH PRIORITY)</h2><table><tr><th>Secret</th><th>Type</th><th>Severity</th><th>Confidence</th><th>Source</th><th>Context</th></tr>`)
+		for _, secret := range secrets {
+			output.WriteString(fmt.Sprintf(`<tr><td class="high">%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+				html.EscapeString(secret.Value), html.EscapeString(secret.Type), secret.Severity, secret.Confidence,
+				html.EscapeString(strings.Join(sourceLocations(secret.Sources), ", ")), snippetHTML(secret.Location)))
 		}
 		output.WriteString(`</table>`)
 	}
 
 	// Endpoints
-	if len(af.Endpoints) > 0 {
-		output.WriteString(`<h2>📍 API Endpoints</h2><table><tr><th>Endpoint</th><th>Sources</th><th>Count</th></tr>`)
-		endpoints := make([]string, 0, len(af.Endpoints))
-		for ep := range af.Endpoints {
-			endpoints = append(endpoints, ep)
-		}
-		sort.Strings(endpoints)
+	if endpoints := redactedFindings(af, types.CategoryEndpoints, redact); len(endpoints) > 0 {
+		output.WriteString(`<h2>📍 API Endpoints</h2><table><tr><th>Method</th><th>Endpoint</th><th>Parameters</th><th>Request</th><th>Sources</th><th>Count</th><th>Context</th></tr>`)
 		for _, ep := range endpoints {
-			sources := af.Endpoints[ep]
-			sourceNames := make([]string, len(sources))
-			for i, src := range sources {
-				sourceNames[i] = src.Source
-			}
-			output.WriteString(fmt.Sprintf(`<tr><td class="endpoint">%s</td><td>%s</td><td>%d</td></tr>`, ep, strings.Join(sourceNames, ", "), len(sources)))
+			output.WriteString(fmt.Sprintf(`<tr><td>%s</td><td class="endpoint">%s</td><td>%s</td><td>%s</td><td>%s</td><td>%d</td><td>%s</td></tr>`,
+				html.EscapeString(strings.TrimSpace(methodPrefix(ep.Request))), html.EscapeString(ep.Value),
+				html.EscapeString(strings.Join(ep.Params, ", ")), html.EscapeString(requestDetails(ep.Request)),
+				html.EscapeString(strings.Join(sourceLocations(ep.Sources), ", ")), len(ep.Sources), snippetHTML(ep.Location)))
 		}
 		output.WriteString(`</table>`)
 	}
 
-	// URLs
-	if len(af.URLs) > 0 {
-		output.WriteString(`<h2>🌐 URLs</h2><table><tr><th>URL</th><th>Sources</th></tr>`)
-		urls := make([]string, 0, len(af.URLs))
-		for u := range af.URLs {
-			urls = append(urls, u)
+	// URLs, emails and files
+	sections := []struct {
+		category string
+		heading  string
+		column   string
+	}{
+		{types.CategoryURLs, "🌐 URLs", "URL"},
+		{types.CategoryEmails, "📧 Emails", "Email"},
+		{types.CategoryFiles, "📄 Files", "File"},
+	}
+	for _, section := range sections {
+		items := redactedFindings(af, section.category, redact)
+		if len(items) == 0 {
+			continue
 		}
-		sort.Strings(urls)
-		for _, u := range urls {
-			sources := af.URLs[u]
-			sourceNames := make([]string, len(sources))
-			for i, src := range sources {
-				sourceNames[i] = src.Source
-			}
-			output.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td></tr>`, u, strings.Join(sourceNames, ", ")))
+		output.WriteString(fmt.Sprintf(`<h2>%s</h2><table><tr><th>%s</th><th>Sources</th><th>Context</th></tr>`, section.heading, section.column))
+		for _, finding := range items {
+			output.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td><td>%s</td></tr>`,
+				html.EscapeString(finding.Value), html.EscapeString(strings.Join(sourceLocations(finding.Sources), ", ")), snippetHTML(finding.Location)))
 		}
 		output.WriteString(`</table>`)
 	}
 
-	// Emails
-	if len(af.Emails) > 0 {
-		output.WriteString(`<h2>📧 Emails</h2><table><tr><th>Email</th><th>Sources</th></tr>`)
-		emails := make([]string, 0, len(af.Emails))
-		for email := range af.Emails {
-			emails = append(emails, email)
-		}
-		sort.Strings(emails)
-		for _, email := range emails {
-			sources := af.Emails[email]
-			sourceNames := make([]string, len(sources))
-			for i, src := range sources {
-				sourceNames[i] = src.Source
-			}
-			output.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td></tr>`, email, strings.Join(sourceNames, ", ")))
-		}
-		output.WriteString(`</table>`)
+	if len(af.Findings) == 0 {
+		output.WriteString(`<div class="section"><p>No findings detected.</p></div>`)
 	}
 
-	// Files
-	if len(af.Files) > 0 {
-		output.WriteString(`<h2>📄 Files</h2><table><tr><th>File</th><th>Sources</th></tr>`)
-		files := make([]string, 0, len(af.Files))
-		for file := range af.Files {
-			files = append(files, file)
-		}
-		sort.Strings(files)
-		for _, file := range files {
-			sources := af.Files[file]
-			sourceNames := make([]string, len(sources))
-			for i, src := range sources {
-				sourceNames[i] = src.Source
-			}
-			output.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td></tr>`, file, strings.Join(sourceNames, ", ")))
+	output.WriteString(`</div></body></html>`)
+
+	return output.String()
+}
+
+// sortedFindings returns the redacted findings of a category. Secrets keep
+// discovery order, every other category is sorted by value.
+func sortedFindings(findings *types.Findings, category string, redact *redactor) []types.Finding {
+	items := findings.ByCategory(category)
+	if category != types.CategorySecrets {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Value < items[j].Value
+		})
+	}
+	for i := range items {
+		items[i] = redact.finding(items[i])
+	}
+	return items
+}
+
+// redactedFindings returns the redacted aggregated findings of a category
+func redactedFindings(af *types.AggregatedFindings, category string, redact *redactor) []*types.AggregatedFinding {
+	items := af.ByCategory(category)
+	for i := range items {
+		items[i] = redact.aggregated(items[i])
+	}
+	return items
+}
+
+// formatLocation renders a source with its line and column, followed by the
+// original position in a bundle, e.g. app.js:1:5230 (webpack:///src/api.js:12:5)
+func formatLocation(source string, loc types.Location) string {
+	if loc.Line == 0 {
+		return source
+	}
+	if loc.Original != nil {
+		return fmt.Sprintf("%s:%d:%d (%s)", source, loc.Line, loc.Column, originalLocation(loc.Original))
+	}
+	return fmt.Sprintf("%s:%d:%d", source, loc.Line, loc.Column)
+}
+
+// originalLocation renders an original position as source:line:column
+func originalLocation(original *types.OriginalLocation) string {
+	if original == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", original.Source, original.Line, original.Column)
+}
+
+// methodPrefix renders the methods of an endpoint's request for prefixing
+// its value, e.g. "GET, POST "
+func methodPrefix(request *types.Request) string {
+	if request == nil || len(request.Methods) == 0 {
+		return ""
+	}
+	return strings.Join(request.Methods, ", ") + " "
+}
+
+// requestDetails renders the query parameters, headers and body keys of an
+// endpoint's request, e.g. "query: page; headers: Authorization"
+func requestDetails(request *types.Request) string {
+	if request == nil {
+		return ""
+	}
+	var details []string
+	for _, d := range []struct {
+		label string
+		names []string
+	}{
+		{"query", request.Query},
+		{"headers", request.Headers},
+		{"body", request.Body},
+	} {
+		if len(d.names) > 0 {
+			details = append(details, d.label+": "+strings.Join(d.names, ", "))
 		}
-		output.WriteString(`</table>`)
 	}
+	return strings.Join(details, "; ")
+}
 
-	if totalCount == 0 {
-		output.WriteString(`<div class="section"><p>No findings detected.</p></div>`)
+// locationSuffix renders a location for appending to a table row
+func locationSuffix(loc types.Location) string {
+	if loc.Line == 0 {
+		return ""
 	}
+	if loc.Original != nil {
+		return fmt.Sprintf(" (line %d, col %d; %s)", loc.Line, loc.Column, originalLocation(loc.Original))
+	}
+	return fmt.Sprintf(" (line %d, col %d)", loc.Line, loc.Column)
+}
 
-	output.WriteString(`</div></body></html>`)
+// locationColumns renders a location as Line, Column and Snippet CSV columns
+func locationColumns(loc types.Location) []string {
+	if loc.Line == 0 {
+		return []string{"", "", ""}
+	}
+	return []string{fmt.Sprintf("%d", loc.Line), fmt.Sprintf("%d", loc.Column), loc.Snippet}
+}
 
-	return output.String()
+// snippetHTML renders a location's snippet as escaped inline code
+func snippetHTML(loc types.Location) string {
+	if loc.Snippet == "" {
+		return ""
+	}
+	return "<code>" + html.EscapeString(loc.Snippet) + "</code>"
+}
+
+// sourceNames returns the source name of each source finding
+func sourceNames(sources []types.SourceFinding) []string {
+	names := make([]string, len(sources))
+	for i, src := range sources {
+		names[i] = src.Source
+	}
+	return names
 }
 
-// Helper function to get sorted keys from map
-func sortedKeys(m map[string]bool) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+// sourceLocations returns each source finding formatted as source:line:column
+func sourceLocations(sources []types.SourceFinding) []string {
+	locations := make([]string, len(sources))
+	for i, src := range sources {
+		locations[i] = formatLocation(src.Source, src.Location)
 	}
-	sort.Strings(keys)
-	return keys
+	return locations
 }
@@ -0,0 +1,266 @@
+package output
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/0xhkx0/jsmap/pkg/sourcemap"
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// sarifSchema is the JSON schema of SARIF 2.1.0 logs
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// sarifFingerprint is the partial fingerprint key of jsmap results
+const sarifFingerprint = "jsmapFinding/v1"
+
+// sarifLevels maps finding severities to SARIF result levels
+var sarifLevels = map[string]string{
+	"critical": "error",
+	"high":     "error",
+	"medium":   "warning",
+	"low":      "note",
+	"info":     "note",
+}
+
+// sarifSecuritySeverity maps finding severities to the numeric scores code
+// scanning dashboards rank results by
+var sarifSecuritySeverity = map[string]string{
+	"critical": "9.5",
+	"high":     "8.0",
+	"medium":   "5.5",
+	"low":      "3.0",
+	"info":     "0.0",
+}
+
+// sarifLog is a SARIF 2.1.0 log
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun is a single run of jsmap
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool describes jsmap and its rules
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver is the tool component producing the results
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule is a detection rule results refer to
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name"`
+	ShortDescription     sarifMessage        `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration  `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+// sarifConfiguration is the default level of a rule
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+// sarifRuleProperties are the rule properties code scanning dashboards read
+type sarifRuleProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity"`
+}
+
+// sarifMessage is a plain text message
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult is a finding in one source
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	RelatedLocations    []sarifLocation   `json:"relatedLocations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]string `json:"properties,omitempty"`
+}
+
+// sarifLocation is where a result was found
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+// sarifPhysicalLocation is a region of an artifact
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+	ContextRegion    *sarifRegion          `json:"contextRegion,omitempty"`
+}
+
+// sarifArtifactLocation is the URI of a scanned file. URIBaseID is set for
+// paths relative to the root of the scanned project.
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// sarifSourceRoot is the URI base of original sources, resolved by code
+// scanning tools to the root of the repository
+const sarifSourceRoot = "%SRCROOT%"
+
+// sarifRegion is the position of a result in its artifact. StartColumn is
+// omitted in context regions, which cover whole lines.
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+// AggregatedToSARIF converts aggregated findings to a SARIF 2.1.0 log with a
+// rule per rule ID and a result per finding and source. Each result carries
+// a fingerprint of its finding and source so dashboards track it across runs.
+func AggregatedToSARIF(af *types.AggregatedFindings, redaction Redaction) string {
+	redact := aggregatedRedactor(af, redaction)
+
+	driver := sarifDriver{
+		Name:           "jsmap",
+		InformationURI: "https://github.com/0xhkx0/jsmap",
+		Rules:          []sarifRule{},
+	}
+	ruleIndex := make(map[string]int)
+	var findings []*types.AggregatedFinding
+	for _, category := range types.Categories {
+		for _, finding := range redactedFindings(af, category, redact) {
+			findings = append(findings, finding)
+			if _, exists := ruleIndex[finding.RuleID]; !exists {
+				ruleIndex[finding.RuleID] = -1
+				driver.Rules = append(driver.Rules, newSARIFRule(finding))
+			}
+		}
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool {
+		return driver.Rules[i].ID < driver.Rules[j].ID
+	})
+	for i, rule := range driver.Rules {
+		ruleIndex[rule.ID] = i
+	}
+
+	results := []sarifResult{}
+	for _, finding := range findings {
+		for _, src := range finding.Sources {
+			result := sarifResult{
+				RuleID:    finding.RuleID,
+				RuleIndex: ruleIndex[finding.RuleID],
+				Level:     sarifLevel(finding.Severity),
+				Message:   sarifMessage{Text: finding.Type + ": " + finding.Value},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: artifactURI(src)},
+					Region:           sarifRegionOf(src.Location, finding.Value),
+					ContextRegion:    sarifContextRegionOf(src.Location),
+				}}},
+				PartialFingerprints: map[string]string{sarifFingerprint: resultFingerprint(finding.ID, src.Source)},
+				Properties:          map[string]string{"confidence": finding.Confidence, "category": finding.Category},
+			}
+			// Report findings in a bundle at their original source, keeping
+			// the bundle position as a related location
+			if original := src.Location.Original; original != nil {
+				bundle := result.Locations[0]
+				bundle.ID = 1
+				bundle.Message = &sarifMessage{Text: "Bundled in " + artifactURI(src)}
+				result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sourcemap.RepoPath(original.Source), URIBaseID: sarifSourceRoot},
+					Region:           &sarifRegion{StartLine: original.Line, StartColumn: original.Column},
+				}}}
+				result.RelatedLocations = []sarifLocation{bundle}
+			}
+			results = append(results, result)
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+	jsonBytes, _ := json.MarshalIndent(log, "", "  ")
+	return string(jsonBytes)
+}
+
+// newSARIFRule describes the rule of a finding
+func newSARIFRule(finding *types.AggregatedFinding) sarifRule {
+	return sarifRule{
+		ID:                   finding.RuleID,
+		Name:                 finding.Type,
+		ShortDescription:     sarifMessage{Text: finding.Type},
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(finding.Severity)},
+		Properties: sarifRuleProperties{
+			Tags:             append([]string{"security", finding.Category}, finding.Tags...),
+			SecuritySeverity: sarifSecuritySeverity[finding.Severity],
+		},
+	}
+}
+
+// sarifLevel returns the SARIF level of a severity
+func sarifLevel(severity string) string {
+	if level, ok := sarifLevels[severity]; ok {
+		return level
+	}
+	return "note"
+}
+
+// sarifRegionOf converts a location to the region of the matched value, or
+// nil if it is unknown. The value is only given as the snippet when it is
+// the literal text at the location, which folded dynamic endpoints are not.
+func sarifRegionOf(loc types.Location, value string) *sarifRegion {
+	if loc.Line == 0 {
+		return nil
+	}
+	region := &sarifRegion{StartLine: loc.Line, StartColumn: loc.Column}
+	if value != "" && strings.Contains(loc.Snippet, value) {
+		region.Snippet = &sarifMessage{Text: value}
+	}
+	return region
+}
+
+// sarifContextRegionOf returns the region holding the context snippet of a
+// location, or nil if there is none
+func sarifContextRegionOf(loc types.Location) *sarifRegion {
+	if loc.Line == 0 || loc.Snippet == "" {
+		return nil
+	}
+	return &sarifRegion{StartLine: loc.Line, Snippet: &sarifMessage{Text: loc.Snippet}}
+}
+
+// artifactURI returns the URI of a source: its URL when fetched, otherwise
+// its file path
+func artifactURI(src types.SourceFinding) string {
+	if src.URL != "" {
+		return src.URL
+	}
+	if filepath.IsAbs(src.Source) {
+		return "file://" + filepath.ToSlash(src.Source)
+	}
+	return strings.TrimPrefix(filepath.ToSlash(src.Source), "./")
+}
+
+// resultFingerprint identifies a finding in a source across runs
+func resultFingerprint(id, source string) string {
+	sum := sha1.Sum([]byte(id + "\x00" + source))
+	return hex.EncodeToString(sum[:8])
+}
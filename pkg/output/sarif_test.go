@@ -0,0 +1,44 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+func TestSARIFRegionsAndOriginalLocations(t *testing.T) {
+	findings := types.NewFindings(false)
+	findings.Add(types.Finding{
+		Category: types.CategoryEndpoints,
+		RuleID:   "endpoint-api",
+		Value:    "/api/v1/cart",
+		Location: types.Location{
+			Line:     1,
+			Column:   5230,
+			Snippet:  `var e=fetch("/api/v1/cart",{method:"POST"})`,
+			Original: &types.OriginalLocation{Source: "webpack://app/./src/api/cart.js", Line: 12, Column: 9},
+		},
+	})
+	af := types.NewAggregatedFindings()
+	af.AddFindings(findings, "https://shop.example.org/main.js", "https://shop.example.org/main.js", 200)
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(AggregatedToSARIF(af, RedactPartial)), &log); err != nil {
+		t.Fatal(err)
+	}
+	result := log.Runs[0].Results[0]
+
+	primary := result.Locations[0].PhysicalLocation
+	if primary.ArtifactLocation.URI != "src/api/cart.js" || primary.ArtifactLocation.URIBaseID != sarifSourceRoot {
+		t.Errorf("original artifact = %+v, want src/api/cart.js under %s", primary.ArtifactLocation, sarifSourceRoot)
+	}
+
+	bundle := result.RelatedLocations[0].PhysicalLocation
+	if bundle.Region == nil || bundle.Region.Snippet == nil || bundle.Region.Snippet.Text != "/api/v1/cart" {
+		t.Errorf("bundle region = %+v, want the matched text as snippet", bundle.Region)
+	}
+	if bundle.ContextRegion == nil || bundle.ContextRegion.Snippet.Text != `var e=fetch("/api/v1/cart",{method:"POST"})` {
+		t.Errorf("bundle context region = %+v, want the context snippet", bundle.ContextRegion)
+	}
+}
@@ -0,0 +1,190 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/0xhkx0/jsmap/pkg/types"
+)
+
+// TemplateData is the data user templates are rendered with. Secret values
+// and snippets are redacted according to the redaction policy.
+type TemplateData struct {
+	// Generated is when the report was rendered
+	Generated time.Time
+	// Sources are the analyzed sources sorted by name
+	Sources []types.SourceFinding
+	// Findings holds every finding in discovery order
+	Findings []*types.AggregatedFinding
+	// Summary counts the findings
+	Summary TemplateSummary
+}
+
+// TemplateSummary counts the findings of a report
+type TemplateSummary struct {
+	Total      int
+	Sources    int
+	ByCategory map[string]int
+	BySeverity map[string]int
+}
+
+// Category returns the findings of a category ordered as in the built-in
+// formats, e.g. {{range .Category "secrets"}}
+func (d TemplateData) Category(category string) []*types.AggregatedFinding {
+	var items []*types.AggregatedFinding
+	for _, finding := range d.Findings {
+		if finding.Category == category {
+			items = append(items, finding)
+		}
+	}
+	if category != types.CategorySecrets {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Value < items[j].Value
+		})
+	}
+	return items
+}
+
+// severityColors holds the report color of each severity
+var severityColors = map[string]string{
+	"critical": "#8b0000",
+	"high":     "#d9534f",
+	"medium":   "#f0ad4e",
+	"low":      "#5bc0de",
+	"info":     "#777777",
+}
+
+// severityRanks orders severities from most severe
+var severityRanks = map[string]int{
+	"critical": 0,
+	"high":     1,
+	"medium":   2,
+	"low":      3,
+	"info":     4,
+}
+
+// confidenceRanks orders confidences from most confident
+var confidenceRanks = map[string]int{
+	"high":   0,
+	"medium": 1,
+	"low":    2,
+}
+
+// templateFuncs are the helper functions available to user templates
+var templateFuncs = map[string]interface{}{
+	"mask":          types.MaskSecret,
+	"severityColor": severityColor,
+	"join":          func(list []string, sep string) string { return strings.Join(list, sep) },
+	"sortBy":        sortBy,
+	"locations":     sourceLocations,
+	"methods":       func(request *types.Request) string { return strings.TrimSpace(methodPrefix(request)) },
+	"lower":         strings.ToLower,
+	"upper":         strings.ToUpper,
+}
+
+// AggregatedToTemplate renders aggregated findings through a user template.
+// Files ending in .html or .htm are rendered with html/template, which
+// escapes values for their HTML context; anything else uses text/template.
+func AggregatedToTemplate(af *types.AggregatedFindings, redaction Redaction, path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	data := newTemplateData(af, redaction)
+	name := filepath.Base(path)
+	var output bytes.Buffer
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		tmpl, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(string(content))
+		if err != nil {
+			return "", err
+		}
+		err = tmpl.Execute(&output, data)
+		if err != nil {
+			return "", err
+		}
+	default:
+		tmpl, err := texttemplate.New(name).Funcs(templateFuncs).Parse(string(content))
+		if err != nil {
+			return "", err
+		}
+		err = tmpl.Execute(&output, data)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return output.String(), nil
+}
+
+// newTemplateData builds the redacted data of a report
+func newTemplateData(af *types.AggregatedFindings, redaction Redaction) TemplateData {
+	redact := aggregatedRedactor(af, redaction)
+	data := TemplateData{
+		Generated: time.Now(),
+		Sources:   af.SortedSources(),
+		Findings:  make([]*types.AggregatedFinding, len(af.Findings)),
+		Summary: TemplateSummary{
+			Total:      len(af.Findings),
+			Sources:    len(af.Sources),
+			ByCategory: make(map[string]int),
+			BySeverity: make(map[string]int),
+		},
+	}
+	for i, finding := range af.Findings {
+		data.Findings[i] = redact.aggregated(finding)
+		data.Summary.ByCategory[finding.Category]++
+		data.Summary.BySeverity[finding.Severity]++
+	}
+	return data
+}
+
+// severityColor returns the report color of a severity
+func severityColor(severity string) string {
+	if color, ok := severityColors[severity]; ok {
+		return color
+	}
+	return severityColors["info"]
+}
+
+// sortBy returns the findings sorted by a field: value, type, category,
+// rule, severity (most severe first), confidence (most confident first) or
+// sources (most sources first). Ties keep their order.
+func sortBy(field string, findings []*types.AggregatedFinding) ([]*types.AggregatedFinding, error) {
+	var less func(a, b *types.AggregatedFinding) bool
+	switch field {
+	case "value":
+		less = func(a, b *types.AggregatedFinding) bool { return a.Value < b.Value }
+	case "type":
+		less = func(a, b *types.AggregatedFinding) bool { return a.Type < b.Type }
+	case "category":
+		less = func(a, b *types.AggregatedFinding) bool { return a.Category < b.Category }
+	case "rule":
+		less = func(a, b *types.AggregatedFinding) bool { return a.RuleID < b.RuleID }
+	case "severity":
+		less = func(a, b *types.AggregatedFinding) bool { return severityRanks[a.Severity] < severityRanks[b.Severity] }
+	case "confidence":
+		less = func(a, b *types.AggregatedFinding) bool {
+			return confidenceRanks[a.Confidence] < confidenceRanks[b.Confidence]
+		}
+	case "sources":
+		less = func(a, b *types.AggregatedFinding) bool { return len(a.Sources) > len(b.Sources) }
+	default:
+		return nil, fmt.Errorf("sortBy: unknown field %q", field)
+	}
+
+	sorted := append([]*types.AggregatedFinding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted, nil
+}
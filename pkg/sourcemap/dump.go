@@ -0,0 +1,235 @@
+package sourcemap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestName is the name of the manifest written to the dump directory
+const ManifestName = "manifest.json"
+
+// unsafePathChars matches characters not allowed in file names on common
+// file systems
+var unsafePathChars = regexp.MustCompile(`[\x00-\x1f<>:"|?*]`)
+
+// ManifestEntry describes an original source written to disk
+type ManifestEntry struct {
+	// Path is where the file was written, relative to the dump directory
+	Path string `json:"path"`
+	// Source is the source as listed in the map
+	Source string `json:"source"`
+	Map    string `json:"map"`
+	Script string `json:"script"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest lists the original sources written to a dump directory
+type Manifest struct {
+	Generated time.Time       `json:"generated"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+// Dumper reconstructs the original source trees of source maps under a
+// directory, one subdirectory per host. It is not safe for concurrent use.
+type Dumper struct {
+	dir     string
+	entries []ManifestEntry
+	// hashes maps every written path to the hash of its content
+	hashes map[string]string
+}
+
+// NewDumper creates a dumper writing under dir
+func NewDumper(dir string) *Dumper {
+	return &Dumper{
+		dir:    dir,
+		hashes: make(map[string]string),
+	}
+}
+
+// Dump writes the embedded sources of the source map of a script to their
+// sanitized paths under the script's host directory and returns how many
+// files it wrote. Sources already written with the same content, e.g.
+// modules shared by several bundles, are skipped; different content at the
+// same path gets a numbered name. A source that cannot be written does not
+// stop the others; the errors of every failed source are returned joined.
+func (d *Dumper) Dump(scriptURL string, sourceMap *SourceMap) (int, error) {
+	host := "local"
+	if u, err := url.Parse(scriptURL); err == nil && u.Host != "" {
+		host = SafePath(u.Host)
+	}
+
+	written := 0
+	var errs []error
+	for i, source := range sourceMap.Sources {
+		content := sourceMap.SourceContent(i)
+		if content == "" {
+			continue
+		}
+
+		// Sources resolved to http(s) URLs go under their own host
+		dir, rel := host, SafePath(sourceMap.SourceURL(i))
+		if u, err := url.Parse(sourceMap.SourceURL(i)); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			dir, rel = SafePath(u.Host), SafePath(u.Path)
+		}
+		if rel == "" {
+			rel = fmt.Sprintf("source-%d.js", i)
+		}
+		sum := sha256.Sum256([]byte(content))
+		hash := hex.EncodeToString(sum[:])
+
+		target, exists := d.target(path.Join(dir, rel), hash)
+		if exists {
+			continue
+		}
+
+		full := filepath.Join(d.dir, filepath.FromSlash(target))
+		if !d.contains(full) {
+			errs = append(errs, fmt.Errorf("refusing to write %s outside %s", source, d.dir))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		d.hashes[target] = hash
+		d.entries = append(d.entries, ManifestEntry{
+			Path:   target,
+			Source: source,
+			Map:    manifestMapURL(sourceMap),
+			Script: scriptURL,
+			Size:   len(content),
+			SHA256: hash,
+		})
+		written++
+	}
+
+	return written, errors.Join(errs...)
+}
+
+// target returns the path to write content with the given hash to, and
+// whether that content was already written there
+func (d *Dumper) target(rel, hash string) (string, bool) {
+	ext := path.Ext(rel)
+	base := strings.TrimSuffix(rel, ext)
+	candidate := rel
+	for n := 2; ; n++ {
+		existing, taken := d.hashes[candidate]
+		if !taken {
+			return candidate, false
+		}
+		if existing == hash {
+			return candidate, true
+		}
+		candidate = fmt.Sprintf("%s.%d%s", base, n, ext)
+	}
+}
+
+// contains reports whether a path lies inside the dump directory
+func (d *Dumper) contains(full string) bool {
+	rel, err := filepath.Rel(d.dir, full)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// Count returns the number of files written
+func (d *Dumper) Count() int {
+	return len(d.entries)
+}
+
+// WriteManifest writes the manifest of every file written, sorted by path,
+// to the dump directory
+func (d *Dumper) WriteManifest() error {
+	manifest := Manifest{
+		Generated: time.Now(),
+		Files:     append([]ManifestEntry{}, d.entries...),
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return err
+	}
+	jsonBytes, _ := json.MarshalIndent(manifest, "", "  ")
+	return os.WriteFile(filepath.Join(d.dir, ManifestName), jsonBytes, 0644)
+}
+
+// manifestMapURL returns the URL a map was found at, without the content
+// of inline maps
+func manifestMapURL(sourceMap *SourceMap) string {
+	if strings.HasPrefix(sourceMap.URL, "data:") {
+		return "inline"
+	}
+	return sourceMap.URL
+}
+
+// SafePath turns a source path or URL into a relative slash-separated path
+// that cannot leave the directory it is joined to. Schemes such as
+// webpack:// are dropped, keeping the namespace or host as the first
+// directory; query strings are dropped; ".." segments only ever remove
+// segments of the path itself; characters file systems reject become "_".
+func SafePath(source string) string {
+	if i := strings.Index(source, "://"); i >= 0 {
+		source = source[i+3:]
+	}
+	if i := strings.IndexAny(source, "?#"); i >= 0 {
+		source = source[:i]
+	}
+	source = strings.ReplaceAll(source, `\`, "/")
+
+	var parts []string
+	for _, part := range strings.Split(source, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(parts) > 0 {
+				parts = parts[:len(parts)-1]
+			}
+			continue
+		}
+
+		part = unsafePathChars.ReplaceAllString(part, "_")
+		// Windows ignores trailing dots and spaces, so "..." could still
+		// name the parent directory
+		part = strings.TrimRight(part, ". ")
+		if part == "" {
+			part = "_"
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// RepoPath turns an original source path into a path relative to the root
+// of the project it came from. It is SafePath without the namespace or host
+// of sources with a scheme, so webpack://app/./src/api.js becomes
+// src/api.js.
+func RepoPath(source string) string {
+	if i := strings.Index(source, "://"); i >= 0 {
+		source = source[i+3:]
+		if j := strings.IndexByte(source, '/'); j >= 0 {
+			source = source[j+1:]
+		} else {
+			source = ""
+		}
+	}
+	return SafePath(source)
+}
@@ -0,0 +1,231 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSafePath(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"../../etc/passwd", "etc/passwd"},
+		{"webpack:///../x", "x"},
+		{"webpack://app/./src/../lib/a.js", "app/lib/a.js"},
+		{`src\components\App.jsx`, "src/components/App.jsx"},
+		{`..\..\windows\win.ini`, "windows/win.ini"},
+		{`src\..\..\..\boot.ini`, "boot.ini"},
+		{"/etc/passwd", "etc/passwd"},
+		{"//server/share/a.js", "server/share/a.js"},
+		{`C:\Windows\system32\a.dll`, "C_/Windows/system32/a.dll"},
+		{"a/.../b.js", "a/_/b.js"},
+		{"a/.. /b.js", "a/_/b.js"},
+		{"a/b./c.js", "a/b/c.js"},
+		{"src/app.js?v=1#top", "src/app.js"},
+		{`a/<b>:c|d*.js`, "a/_b__c_d_.js"},
+		{"", ""},
+		{"../..", ""},
+	}
+	for _, tt := range tests {
+		if got := SafePath(tt.source); got != tt.want {
+			t.Errorf("SafePath(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestRepoPath(t *testing.T) {
+	tests := map[string]string{
+		"webpack://app/./src/api.js": "src/api.js",
+		"webpack:///../x":            "x",
+		"webpack://app":              "",
+		"src/../lib/a.js":            "lib/a.js",
+	}
+	for source, want := range tests {
+		if got := RepoPath(source); got != want {
+			t.Errorf("RepoPath(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+// dumpMap builds a map with the given sources and contents
+func dumpMap(url string, sources, contents []string) *SourceMap {
+	return &SourceMap{Version: 3, URL: url, Sources: sources, SourcesContent: contents}
+}
+
+// dumpedFiles lists the files under dir, relative and slash-separated
+func dumpedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestDumpStaysInsideDirectory(t *testing.T) {
+	dir := t.TempDir()
+	d := NewDumper(dir)
+
+	sourceMap := dumpMap("https://shop.example.org/main.js.map", []string{
+		"webpack:///../../evil.js",
+		"webpack://app/./src/a.js",
+		`src\util.js`,
+		"/abs/z.js",
+		"https://cdn.other.org/lib/x.js",
+		"missing.js",
+	}, []string{"evil", "a", "util", "z", "x", ""})
+
+	written, err := d.Dump("https://shop.example.org/main.js", sourceMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 5 || d.Count() != 5 {
+		t.Errorf("wrote %d files, count %d, want 5", written, d.Count())
+	}
+
+	want := []string{
+		"cdn.other.org/lib/x.js",
+		"shop.example.org/abs/z.js",
+		"shop.example.org/app/src/a.js",
+		"shop.example.org/evil.js",
+		"shop.example.org/src/util.js",
+	}
+	if got := dumpedFiles(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+}
+
+func TestDumpDuplicatesAndCollisions(t *testing.T) {
+	dir := t.TempDir()
+	d := NewDumper(dir)
+
+	first := dumpMap("https://shop.example.org/a.js.map", []string{"webpack://app/src/shared.js", "webpack://app/src/a.js"}, []string{"shared", "a"})
+	if written, err := d.Dump("https://shop.example.org/a.js", first); err != nil || written != 2 {
+		t.Fatalf("first dump wrote %d files: %v", written, err)
+	}
+
+	// The shared module is skipped, the different a.js gets numbered names
+	second := dumpMap("https://shop.example.org/b.js.map", []string{"webpack://app/src/shared.js", "webpack://app/src/a.js"}, []string{"shared", "other a"})
+	if written, err := d.Dump("https://shop.example.org/b.js", second); err != nil || written != 1 {
+		t.Fatalf("second dump wrote %d files: %v", written, err)
+	}
+	third := dumpMap("https://shop.example.org/c.js.map", []string{"webpack://app/src/a.js"}, []string{"third a"})
+	if written, err := d.Dump("https://shop.example.org/c.js", third); err != nil || written != 1 {
+		t.Fatalf("third dump wrote %d files: %v", written, err)
+	}
+
+	want := map[string]string{
+		"shop.example.org/app/src/shared.js": "shared",
+		"shop.example.org/app/src/a.js":      "a",
+		"shop.example.org/app/src/a.2.js":    "other a",
+		"shop.example.org/app/src/a.3.js":    "third a",
+	}
+	for rel, content := range want {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v, want %q", rel, data, err, content)
+		}
+	}
+	if d.Count() != len(want) {
+		t.Errorf("count = %d, want %d", d.Count(), len(want))
+	}
+}
+
+func TestDumpContinuesAfterWriteErrors(t *testing.T) {
+	dir := t.TempDir()
+	// A file where a directory is needed makes that source fail
+	if err := os.MkdirAll(filepath.Join(dir, "shop.example.org"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shop.example.org", "blocked"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDumper(dir)
+	sourceMap := dumpMap("https://shop.example.org/main.js.map", []string{"blocked/a.js", "src/b.js", "blocked/c.js", "src/d.js"}, []string{"a", "b", "c", "d"})
+	written, err := d.Dump("https://shop.example.org/main.js", sourceMap)
+	if err == nil {
+		t.Error("Dump reported no error for the blocked sources")
+	}
+	if written != 2 || d.Count() != 2 {
+		t.Errorf("wrote %d files, count %d, want 2", written, d.Count())
+	}
+	for _, rel := range []string{"src/b.js", "src/d.js"} {
+		if _, err := os.Stat(filepath.Join(dir, "shop.example.org", rel)); err != nil {
+			t.Errorf("%s not written: %v", rel, err)
+		}
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	d := NewDumper(dir)
+
+	inline := dumpMap("data:application/json;base64,e30=", []string{"webpack://app/src/b.js"}, []string{"bb"})
+	remote := dumpMap("https://shop.example.org/main.js.map", []string{"webpack://app/src/a.js"}, []string{"a"})
+	if _, err := d.Dump("https://shop.example.org/inline.js", inline); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Dump("https://shop.example.org/main.js", remote); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.WriteManifest(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Generated.IsZero() {
+		t.Error("manifest has no generation time")
+	}
+
+	want := []ManifestEntry{
+		{
+			Path:   "shop.example.org/app/src/a.js",
+			Source: "webpack://app/src/a.js",
+			Map:    "https://shop.example.org/main.js.map",
+			Script: "https://shop.example.org/main.js",
+			Size:   1,
+			SHA256: "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb",
+		},
+		{
+			Path:   "shop.example.org/app/src/b.js",
+			Source: "webpack://app/src/b.js",
+			Map:    "inline",
+			Script: "https://shop.example.org/inline.js",
+			Size:   2,
+			SHA256: "3b64db95cb55c763391c707108489ae18b4112d783300de38e033b4c98c3deaf",
+		},
+	}
+	if !reflect.DeepEqual(manifest.Files, want) {
+		t.Errorf("manifest files = %+v, want %+v", manifest.Files, want)
+	}
+
+	// Every entry names a file with the recorded size
+	for _, entry := range manifest.Files {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(entry.Path)))
+		if err != nil || int(info.Size()) != entry.Size {
+			t.Errorf("%s: %v, want a file of %d bytes", entry.Path, err, entry.Size)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package sourcemap
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// base64Digits are the digits of Base64 VLQ values, in value order
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// Mapping maps a position of the generated file to its original source.
+// Lines and columns are 0-based as in the map. Source and Name index the
+// map's sources and names, or are -1 when the segment has none.
+type Mapping struct {
+	GeneratedLine   int
+	GeneratedColumn int
+	Source          int
+	OriginalLine    int
+	OriginalColumn  int
+	Name            int
+}
+
+// Position is an original position. Line and Column are 1-based.
+type Position struct {
+	Source string
+	Line   int
+	Column int
+	Name   string
+}
+
+// DecodeMappings decodes the Base64 VLQ mappings of a source map into the
+// segments of each generated line, sorted by generated column
+func DecodeMappings(mappings string) ([][]Mapping, error) {
+	var lines [][]Mapping
+	source, originalLine, originalColumn, name := 0, 0, 0, 0
+
+	for lineNum, line := range strings.Split(mappings, ";") {
+		var segments []Mapping
+		column := 0
+		for _, segment := range strings.Split(line, ",") {
+			if segment == "" {
+				continue
+			}
+			fields, err := decodeVLQ(segment)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum+1, err)
+			}
+			if len(fields) != 1 && len(fields) != 4 && len(fields) != 5 {
+				return nil, fmt.Errorf("line %d: segment %q has %d fields", lineNum+1, segment, len(fields))
+			}
+
+			// Every field but the generated column is relative to the
+			// previous segment, across lines
+			column += fields[0]
+			mapping := Mapping{GeneratedLine: lineNum, GeneratedColumn: column, Source: -1, Name: -1}
+			if len(fields) >= 4 {
+				source += fields[1]
+				originalLine += fields[2]
+				originalColumn += fields[3]
+				mapping.Source, mapping.OriginalLine, mapping.OriginalColumn = source, originalLine, originalColumn
+			}
+			if len(fields) == 5 {
+				name += fields[4]
+				mapping.Name = name
+			}
+			segments = append(segments, mapping)
+		}
+
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].GeneratedColumn < segments[j].GeneratedColumn
+		})
+		lines = append(lines, segments)
+	}
+
+	return lines, nil
+}
+
+// decodeVLQ decodes the Base64 VLQ values of a segment
+func decodeVLQ(segment string) ([]int, error) {
+	var values []int
+	value, shift := 0, 0
+	for i := 0; i < len(segment); i++ {
+		digit := strings.IndexByte(base64Digits, segment[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid Base64 VLQ digit %q", segment[i])
+		}
+
+		// The sixth bit continues the value, the lowest bit of the
+		// completed value is its sign
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			if shift > 30 {
+				return nil, fmt.Errorf("Base64 VLQ value overflows in %q", segment)
+			}
+			continue
+		}
+		if value&1 != 0 {
+			value = -(value >> 1)
+		} else {
+			value >>= 1
+		}
+		values = append(values, value)
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, fmt.Errorf("truncated Base64 VLQ value in %q", segment)
+	}
+	return values, nil
+}
+
+// decode decodes the mappings once. A map with malformed mappings still
+// provides its sources, so the error is kept rather than failing Parse.
+func (sm *SourceMap) decode() {
+	sm.decodeOnce.Do(func() {
+		sm.lines, sm.decodeErr = DecodeMappings(sm.Mappings)
+	})
+}
+
+// flatten merges the sections of an index map into a single map. Sources
+// keep their section's sourceRoot; mappings are shifted by the section
+// offset and renumbered into the merged sources and names. Sections with
+// malformed mappings still contribute their sources.
+func (sm *SourceMap) flatten() {
+	var lines [][]Mapping
+	for _, section := range sm.Sections {
+		child := section.Map
+		if child == nil {
+			continue
+		}
+		if len(child.Sections) > 0 {
+			child.flatten()
+		}
+		childLines, _ := child.Decode()
+
+		sourceOffset, nameOffset := len(sm.Sources), len(sm.Names)
+		for i := range child.Sources {
+			sm.Sources = append(sm.Sources, child.rootedSource(i))
+			sm.SourcesContent = append(sm.SourcesContent, child.SourceContent(i))
+		}
+		sm.Names = append(sm.Names, child.Names...)
+
+		for l, segments := range childLines {
+			line := section.Offset.Line + l
+			for len(lines) <= line {
+				lines = append(lines, nil)
+			}
+			for _, mapping := range segments {
+				mapping.GeneratedLine = line
+				if l == 0 {
+					mapping.GeneratedColumn += section.Offset.Column
+				}
+				if mapping.Source >= 0 {
+					mapping.Source += sourceOffset
+				}
+				if mapping.Name >= 0 {
+					mapping.Name += nameOffset
+				}
+				lines[line] = append(lines[line], mapping)
+			}
+		}
+	}
+
+	for _, segments := range lines {
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].GeneratedColumn < segments[j].GeneratedColumn
+		})
+	}
+	sm.SourceRoot = ""
+	sm.Sections = nil
+	sm.decodeOnce.Do(func() {
+		sm.lines = lines
+	})
+}
+
+// Decode returns the decoded mappings of each generated line
+func (sm *SourceMap) Decode() ([][]Mapping, error) {
+	sm.decode()
+	return sm.lines, sm.decodeErr
+}
+
+// OriginalPosition returns the original position of a 1-based line and
+// column of the generated file, taken from the closest segment at or
+// before the column
+func (sm *SourceMap) OriginalPosition(line, column int) (Position, bool) {
+	sm.decode()
+	if line < 1 || line > len(sm.lines) {
+		return Position{}, false
+	}
+
+	segments := sm.lines[line-1]
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].GeneratedColumn > column-1
+	}) - 1
+	if i < 0 || segments[i].Source < 0 || segments[i].Source >= len(sm.Sources) {
+		return Position{}, false
+	}
+
+	mapping := segments[i]
+	position := Position{
+		Source: sm.SourceURL(mapping.Source),
+		Line:   mapping.OriginalLine + 1,
+		Column: mapping.OriginalColumn + 1,
+	}
+	if mapping.Name >= 0 && mapping.Name < len(sm.Names) {
+		position.Name = sm.Names[mapping.Name]
+	}
+	return position, true
+}
+
+// SourceURL returns the real path of the i-th source: prefixed with
+// sourceRoot, resolved against the map's URL when relative and with dot
+// segments removed, e.g. webpack://app/./src/api.js -> webpack://app/src/api.js
+func (sm *SourceMap) SourceURL(i int) string {
+	if i < 0 || i >= len(sm.Sources) {
+		return ""
+	}
+
+	source := sm.rootedSource(i)
+	u, err := url.Parse(source)
+	if err != nil {
+		return source
+	}
+	if u.Scheme == "" && u.Host == "" {
+		if base, err := url.Parse(sm.base); err == nil && (base.Scheme == "http" || base.Scheme == "https") {
+			return base.ResolveReference(u).String()
+		}
+		return path.Clean(source)
+	}
+	if u.Path != "" {
+		trailing := strings.HasSuffix(u.Path, "/")
+		u.Path = path.Clean(u.Path)
+		if trailing {
+			u.Path += "/"
+		}
+	}
+	return u.String()
+}
+
+// rootedSource returns the i-th source prefixed with sourceRoot
+func (sm *SourceMap) rootedSource(i int) string {
+	source := sm.Sources[i]
+	if sm.SourceRoot != "" && !hasScheme(source) {
+		source = strings.TrimSuffix(sm.SourceRoot, "/") + "/" + strings.TrimPrefix(source, "/")
+	}
+	return source
+}
+
+// SourceContent returns the embedded content of the i-th source, or "" if
+// the map does not include it
+func (sm *SourceMap) SourceContent(i int) string {
+	if i < 0 || i >= len(sm.SourcesContent) {
+		return ""
+	}
+	return sm.SourcesContent[i]
+}
+
+// SetSourceContent sets the content of the i-th source, e.g. after
+// fetching a source the map does not embed
+func (sm *SourceMap) SetSourceContent(i int, content string) {
+	if i < 0 || i >= len(sm.Sources) {
+		return
+	}
+	for len(sm.SourcesContent) < len(sm.Sources) {
+		sm.SourcesContent = append(sm.SourcesContent, "")
+	}
+	sm.SourcesContent[i] = content
+}
+
+// hasScheme reports whether a source is an absolute URL such as
+// webpack:///src/app.js or https://cdn.example.com/app.ts
+func hasScheme(source string) bool {
+	u, err := url.Parse(source)
+	return err == nil && u.Scheme != ""
+}
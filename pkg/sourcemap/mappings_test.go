@@ -0,0 +1,190 @@
+package sourcemap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeVLQ(t *testing.T) {
+	tests := []struct {
+		segment string
+		want    []int
+	}{
+		{"A", []int{0}},
+		{"C", []int{1}},
+		{"D", []int{-1}},
+		{"B", []int{0}},
+		{"f", []int{-15}},
+		{"gB", []int{16}},
+		{"hB", []int{-16}},
+		{"2H", []int{123}},
+		{"3H", []int{-123}},
+		{"ggggE", []int{1 << 21}},
+		{"AAgBC", []int{0, 0, 16, 1}},
+		{"IACEA", []int{4, 0, 1, 2, 0}},
+	}
+	for _, tt := range tests {
+		got, err := decodeVLQ(tt.segment)
+		if err != nil {
+			t.Errorf("decodeVLQ(%q): %v", tt.segment, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("decodeVLQ(%q) = %v, want %v", tt.segment, got, tt.want)
+		}
+	}
+
+	for _, segment := range []string{"g", "AAg", "A!", "gggggggB"} {
+		if _, err := decodeVLQ(segment); err == nil {
+			t.Errorf("decodeVLQ(%q) succeeded, want an error", segment)
+		}
+	}
+}
+
+func TestDecodeMappings(t *testing.T) {
+	// Source, original line and column and name carry over across lines and
+	// empty segments; the generated column restarts on every line
+	got, err := DecodeMappings("AAAA,,EAAE;;AACA,CAACA;EAAE,DACDC;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]Mapping{
+		{
+			{GeneratedLine: 0, GeneratedColumn: 0, Source: 0, OriginalLine: 0, OriginalColumn: 0, Name: -1},
+			{GeneratedLine: 0, GeneratedColumn: 2, Source: 0, OriginalLine: 0, OriginalColumn: 2, Name: -1},
+		},
+		nil,
+		{
+			{GeneratedLine: 2, GeneratedColumn: 0, Source: 0, OriginalLine: 1, OriginalColumn: 2, Name: -1},
+			{GeneratedLine: 2, GeneratedColumn: 1, Source: 0, OriginalLine: 1, OriginalColumn: 3, Name: 0},
+		},
+		{
+			// Segments are sorted by generated column
+			{GeneratedLine: 3, GeneratedColumn: 1, Source: 0, OriginalLine: 2, OriginalColumn: 4, Name: 1},
+			{GeneratedLine: 3, GeneratedColumn: 2, Source: 0, OriginalLine: 1, OriginalColumn: 5, Name: -1},
+		},
+		nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeMappings =\n%+v\nwant\n%+v", got, want)
+	}
+
+	lines, err := DecodeMappings("A,C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines[0][0].Source != -1 || lines[0][1].GeneratedColumn != 1 {
+		t.Errorf("generated-only segments = %+v, want no source", lines[0])
+	}
+	if _, err := DecodeMappings("AAAA;AAA"); err == nil {
+		t.Error("DecodeMappings accepted a segment with 3 fields")
+	}
+	if _, err := DecodeMappings("AAAA;A!AA"); err == nil {
+		t.Error("DecodeMappings accepted an invalid digit")
+	}
+}
+
+func TestOriginalPosition(t *testing.T) {
+	sourceMap := &SourceMap{
+		Version: 3,
+		Sources: []string{"a.js", "b.js"},
+		Names:   []string{"foo"},
+		// Line 1: column 0 -> a.js 1:1, column 4 -> a.js 2:3 named foo
+		// Line 2: empty
+		// Line 3: column 2 -> b.js 1:3
+		Mappings: "AAAA,IACEA;;ECDA",
+	}
+
+	tests := []struct {
+		line, column int
+		want         Position
+		ok           bool
+	}{
+		{1, 1, Position{Source: "a.js", Line: 1, Column: 1}, true},
+		// The last column covered by the first segment
+		{1, 4, Position{Source: "a.js", Line: 1, Column: 1}, true},
+		{1, 5, Position{Source: "a.js", Line: 2, Column: 3, Name: "foo"}, true},
+		// Past the last segment of the line
+		{1, 500, Position{Source: "a.js", Line: 2, Column: 3, Name: "foo"}, true},
+		{2, 1, Position{}, false},
+		// Before the first segment of the line
+		{3, 2, Position{}, false},
+		{3, 3, Position{Source: "b.js", Line: 1, Column: 3}, true},
+		{0, 1, Position{}, false},
+		{4, 1, Position{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := sourceMap.OriginalPosition(tt.line, tt.column)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("OriginalPosition(%d, %d) = %+v, %v, want %+v, %v", tt.line, tt.column, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFlattenSections(t *testing.T) {
+	sourceMap, err := Parse([]byte(`{
+		"version": 3,
+		"sections": [
+			{"offset": {"line": 0, "column": 0}, "map": {
+				"version": 3, "sources": ["a.js"], "names": ["x"], "mappings": "AAAAA"
+			}},
+			{"offset": {"line": 1, "column": 10}, "map": {
+				"version": 3, "sourceRoot": "lib", "sources": ["b.js", "c.js"], "names": ["y", "z"],
+				"sourcesContent": ["b", "c"], "mappings": "AAAAA,ECAAC;AAAA"
+			}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a.js", "lib/b.js", "lib/c.js"}; !reflect.DeepEqual(sourceMap.Sources, want) {
+		t.Errorf("sources = %q, want %q", sourceMap.Sources, want)
+	}
+	if want := []string{"", "b", "c"}; !reflect.DeepEqual(sourceMap.SourcesContent, want) {
+		t.Errorf("sources content = %q, want %q", sourceMap.SourcesContent, want)
+	}
+	if want := []string{"x", "y", "z"}; !reflect.DeepEqual(sourceMap.Names, want) {
+		t.Errorf("names = %q, want %q", sourceMap.Names, want)
+	}
+
+	lines, err := sourceMap.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]Mapping{
+		{
+			{GeneratedLine: 0, GeneratedColumn: 0, Source: 0, OriginalLine: 0, OriginalColumn: 0, Name: 0},
+		},
+		{
+			// The column offset only applies to the first line of a section
+			{GeneratedLine: 1, GeneratedColumn: 10, Source: 1, OriginalLine: 0, OriginalColumn: 0, Name: 1},
+			{GeneratedLine: 1, GeneratedColumn: 12, Source: 2, OriginalLine: 0, OriginalColumn: 0, Name: 2},
+		},
+		{
+			{GeneratedLine: 2, GeneratedColumn: 0, Source: 2, OriginalLine: 0, OriginalColumn: 0, Name: -1},
+		},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("mappings =\n%+v\nwant\n%+v", lines, want)
+	}
+
+	tests := []struct {
+		line, column int
+		want         Position
+		ok           bool
+	}{
+		{1, 1, Position{Source: "a.js", Line: 1, Column: 1, Name: "x"}, true},
+		{2, 5, Position{}, false},
+		{2, 11, Position{Source: "lib/b.js", Line: 1, Column: 1, Name: "y"}, true},
+		{2, 13, Position{Source: "lib/c.js", Line: 1, Column: 1, Name: "z"}, true},
+		{3, 1, Position{Source: "lib/c.js", Line: 1, Column: 1}, true},
+	}
+	for _, tt := range tests {
+		got, ok := sourceMap.OriginalPosition(tt.line, tt.column)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("OriginalPosition(%d, %d) = %+v, %v, want %+v, %v", tt.line, tt.column, got, ok, tt.want, tt.ok)
+		}
+	}
+}
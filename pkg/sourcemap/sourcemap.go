@@ -1,96 +1,258 @@
 package sourcemap
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
+	"sync"
+
+	"github.com/0xhkx0/jsmap/pkg/client"
+)
+
+// Ways a source map can be discovered, in the order they are tried
+const (
+	DiscoverySourceMapHeader  = "SourceMap header"
+	DiscoveryXSourceMapHeader = "X-SourceMap header"
+	DiscoveryInline           = "inline data URI"
+	DiscoveryComment          = "sourceMappingURL comment"
+	DiscoveryConvention       = "conventional URL"
 )
 
 // SourceMap represents a JavaScript source map
 type SourceMap struct {
 	Version        int      `json:"version"`
+	SourceRoot     string   `json:"sourceRoot"`
 	Sources        []string `json:"sources"`
 	SourcesContent []string `json:"sourcesContent"`
 	Names          []string `json:"names"`
 	Mappings       string   `json:"mappings"`
 	File           string   `json:"file"`
+	// Sections holds the maps of an index map, which are flattened into
+	// Sources, SourcesContent, Names and the decoded mappings by Parse
+	Sections []Section `json:"sections,omitempty"`
+
+	// URL is where the map was found; inline maps keep their data URI
+	URL string `json:"-"`
+	// Discovery is how the map was found, one of the Discovery constants
+	Discovery string `json:"-"`
+
+	// base is the URL relative sources resolve against
+	base       string
+	decodeOnce sync.Once
+	lines      [][]Mapping
+	decodeErr  error
 }
 
-// FetchSourceMap attempts to fetch the source map for a JS file
-func FetchSourceMap(jsURL string, httpClient *http.Client, verbose bool) (*SourceMap, error) {
-	// Try common source map URL patterns
-	mapURLs := []string{}
-	
-	// Pattern 1: file.js -> file.js.map
-	if strings.HasSuffix(jsURL, ".js") {
-		mapURLs = append(mapURLs, jsURL+".map")
-	}
-	
-	// Pattern 2: file.js?query -> file.js.map
-	if strings.Contains(jsURL, ".js?") {
-		parts := strings.Split(jsURL, "?")
-		mapURLs = append(mapURLs, parts[0]+".map")
-	}
+// Section is a map of an index map, applying from an offset of the
+// generated file. Sections referring to a map by URL are not supported.
+type Section struct {
+	Offset struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"offset"`
+	Map *SourceMap `json:"map"`
+}
 
-	for _, mapURL := range mapURLs {
-		if verbose {
-			fmt.Printf("[*] Trying source map: %s\n", mapURL)
-		}
+// sourceMappingURLPattern matches //# sourceMappingURL= comments, including
+// the legacy //@ form and the /*# ... */ form used in CSS-style bundles
+var sourceMappingURLPattern = regexp.MustCompile(`(?m)(?://|/\*)[#@][ \t]*sourceMappingURL=[ \t]*(\S+?)[ \t]*(?:\*/)?[ \t]*\r?$`)
 
-		req, err := http.NewRequest("GET", mapURL, nil)
-		if err != nil {
-			continue
-		}
+// mapReference is a candidate location of a source map
+type mapReference struct {
+	url       string
+	discovery string
+}
 
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+// Attempt is a download of a candidate source map
+type Attempt struct {
+	URL        string
+	Discovery  string
+	StatusCode int
+	// Err is why the candidate was rejected, or nil for the map found
+	Err error
+}
 
-		resp, err := httpClient.Do(req)
+// FetchSourceMap attempts to find and fetch the source map of a JS file
+// given its content and response headers. It checks the SourceMap and
+// X-SourceMap headers, then the last sourceMappingURL comment, which may be
+// an inline data URI, then the conventional file.js.map names. Maps are
+// downloaded with fetch, normally the client the JS file was fetched with,
+// so they get the same cookie, headers and proxy. Every download is
+// returned as an attempt, whether or not a map was found.
+func FetchSourceMap(jsURL, content string, header http.Header, fetch func(string) (*client.Response, error), verbose bool) (*SourceMap, []Attempt, error) {
+	var attempts []Attempt
+	for _, ref := range mapReferences(jsURL, content, header) {
+		var sourceMap *SourceMap
+		var err error
+		if strings.HasPrefix(ref.url, "data:") {
+			if verbose {
+				fmt.Printf("[*] Decoding inline source map of %s\n", jsURL)
+			}
+			sourceMap, err = decodeDataURI(ref.url)
+		} else {
+			if verbose {
+				fmt.Printf("[*] Trying source map (%s): %s\n", ref.discovery, ref.url)
+			}
+			var statusCode int
+			sourceMap, statusCode, err = fetchMap(ref.url, fetch)
+			attempts = append(attempts, Attempt{URL: ref.url, Discovery: ref.discovery, StatusCode: statusCode, Err: err})
+		}
 		if err != nil {
+			if verbose {
+				fmt.Printf("[!] Source map %s: %v\n", ref.discovery, err)
+			}
 			continue
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != 200 {
-			continue
+		sourceMap.URL = ref.url
+		sourceMap.Discovery = ref.discovery
+		sourceMap.base = ref.url
+		if ref.discovery == DiscoveryInline {
+			sourceMap.base = jsURL
 		}
+		if verbose {
+			fmt.Printf("[+] Found source map via %s with %d source files\n", ref.discovery, len(sourceMap.Sources))
+		}
+		return sourceMap, attempts, nil
+	}
 
-		var sourceMap SourceMap
-		if err := json.NewDecoder(resp.Body).Decode(&sourceMap); err != nil {
-			continue
+	return nil, attempts, fmt.Errorf("no valid source map found")
+}
+
+// mapReferences lists the candidate map locations of a JS file in priority
+// order, resolved against the JS URL and without duplicates
+func mapReferences(jsURL, content string, header http.Header) []mapReference {
+	var refs []mapReference
+	seen := make(map[string]bool)
+	add := func(ref, discovery string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return
 		}
+		if !strings.HasPrefix(ref, "data:") {
+			ref = resolve(jsURL, ref)
+		}
+		if ref != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, mapReference{url: ref, discovery: discovery})
+		}
+	}
 
-		// Validate it's actually a source map
-		if sourceMap.Version > 0 && len(sourceMap.Sources) > 0 {
-			if verbose {
-				fmt.Printf("[+] Found source map with %d source files\n", len(sourceMap.Sources))
-			}
-			return &sourceMap, nil
+	if header != nil {
+		add(header.Get("SourceMap"), DiscoverySourceMapHeader)
+		add(header.Get("X-SourceMap"), DiscoveryXSourceMapHeader)
+	}
+
+	// Only the last comment applies, as in browsers
+	if matches := sourceMappingURLPattern.FindAllStringSubmatch(content, -1); len(matches) > 0 {
+		ref := matches[len(matches)-1][1]
+		if strings.HasPrefix(ref, "data:") {
+			add(ref, DiscoveryInline)
+		} else {
+			add(ref, DiscoveryComment)
 		}
 	}
 
-	return nil, fmt.Errorf("no valid source map found")
+	// Pattern 1: file.js -> file.js.map
+	if strings.HasSuffix(jsURL, ".js") {
+		add(jsURL+".map", DiscoveryConvention)
+	}
+
+	// Pattern 2: file.js?query -> file.js.map
+	if strings.Contains(jsURL, ".js?") {
+		parts := strings.Split(jsURL, "?")
+		add(parts[0]+".map", DiscoveryConvention)
+	}
+
+	return refs
 }
 
-// ExtractOriginalSource combines all source files from a source map
-func ExtractOriginalSource(sourceMap *SourceMap) string {
-	if len(sourceMap.SourcesContent) == 0 {
+// resolve resolves a map reference against the URL of its JS file
+func resolve(jsURL, ref string) string {
+	base, err := url.Parse(jsURL)
+	if err != nil {
 		return ""
 	}
+	resolved, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	return resolved.String()
+}
 
-	var combined strings.Builder
-	for i, content := range sourceMap.SourcesContent {
-		if content == "" {
-			continue
+// fetchMap downloads and parses the source map at mapURL, returning the
+// status code of the response, or 0 if there was none
+func fetchMap(mapURL string, fetch func(string) (*client.Response, error)) (*SourceMap, int, error) {
+	resp, err := fetch(mapURL)
+	if err != nil {
+		if resp != nil {
+			return nil, resp.StatusCode, err
+		}
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, resp.StatusCode, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	sourceMap, err := Parse([]byte(resp.Body))
+	return sourceMap, resp.StatusCode, err
+}
+
+// decodeDataURI parses an inline source map, either base64 or percent-encoded
+func decodeDataURI(uri string) (*SourceMap, error) {
+	meta, data, found := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !found {
+		return nil, fmt.Errorf("malformed data URI")
+	}
+
+	if strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+			if err != nil {
+				return nil, err
+			}
 		}
+		return Parse(decoded)
+	}
 
-		// Add file separator comment
-		if i < len(sourceMap.Sources) {
-			combined.WriteString(fmt.Sprintf("\n\n// ========== SOURCE: %s ==========\n\n", sourceMap.Sources[i]))
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, err
+	}
+	return Parse([]byte(decoded))
+}
+
+// Parse parses a source map, skipping the )]}' line some servers prepend
+// to prevent JSON hijacking
+func Parse(data []byte) (*SourceMap, error) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, ")]}") {
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
 		}
-		
-		combined.WriteString(content)
 	}
 
-	return combined.String()
+	var sourceMap SourceMap
+	if err := json.Unmarshal([]byte(text), &sourceMap); err != nil {
+		return nil, err
+	}
+
+	if len(sourceMap.Sections) > 0 {
+		sourceMap.flatten()
+	}
+
+	// Validate it's actually a source map
+	if sourceMap.Version <= 0 || len(sourceMap.Sources) == 0 {
+		return nil, fmt.Errorf("not a source map")
+	}
+	return &sourceMap, nil
 }
@@ -0,0 +1,161 @@
+package sourcemap
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/0xhkx0/jsmap/pkg/client"
+)
+
+// testMap is a minimal valid source map
+const testMap = `{"version":3,"sources":["src/app.js"],"names":[],"mappings":"AAAA"}`
+
+// fakeFetch serves responses from a map of URL to status code and body
+func fakeFetch(pages map[string]client.Response) func(string) (*client.Response, error) {
+	return func(u string) (*client.Response, error) {
+		page, ok := pages[u]
+		if !ok {
+			return &client.Response{URL: u, StatusCode: 404, Body: "Not Found"}, nil
+		}
+		page.URL = u
+		return &page, nil
+	}
+}
+
+func TestMapReferencesPriority(t *testing.T) {
+	jsURL := "https://cdn.example.org/js/app.js?v=3"
+	header := http.Header{}
+	header.Set("SourceMap", "/maps/a.map")
+	header.Set("X-SourceMap", "b.map")
+	content := "//# sourceMappingURL=old.map\nrun();\n//# sourceMappingURL=c.map\n"
+
+	want := []mapReference{
+		{url: "https://cdn.example.org/maps/a.map", discovery: DiscoverySourceMapHeader},
+		{url: "https://cdn.example.org/js/b.map", discovery: DiscoveryXSourceMapHeader},
+		{url: "https://cdn.example.org/js/c.map", discovery: DiscoveryComment},
+		{url: "https://cdn.example.org/js/app.js.map", discovery: DiscoveryConvention},
+	}
+	if got := mapReferences(jsURL, content, header); !reflect.DeepEqual(got, want) {
+		t.Errorf("mapReferences = %+v, want %+v", got, want)
+	}
+}
+
+func TestMapReferencesInlineAndDuplicates(t *testing.T) {
+	jsURL := "https://cdn.example.org/app.js"
+	inline := "data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(testMap))
+	header := http.Header{}
+	header.Set("X-SourceMap", "app.js.map")
+
+	want := []mapReference{
+		{url: "https://cdn.example.org/app.js.map", discovery: DiscoveryXSourceMapHeader},
+		{url: inline, discovery: DiscoveryInline},
+	}
+	got := mapReferences(jsURL, "run();\n/*# sourceMappingURL="+inline+" */", header)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mapReferences = %+v, want %+v", got, want)
+	}
+}
+
+func TestFetchSourceMapFallsBack(t *testing.T) {
+	jsURL := "https://cdn.example.org/app.js"
+	header := http.Header{}
+	header.Set("SourceMap", "/missing.map")
+	fetch := fakeFetch(map[string]client.Response{
+		"https://cdn.example.org/broken.map": {StatusCode: 200, Body: "<html>not a map</html>"},
+		"https://cdn.example.org/app.js.map": {StatusCode: 200, Body: testMap},
+	})
+
+	sourceMap, attempts, err := FetchSourceMap(jsURL, "//# sourceMappingURL=broken.map", header, fetch, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sourceMap.Discovery != DiscoveryConvention || sourceMap.URL != "https://cdn.example.org/app.js.map" {
+		t.Errorf("found %s via %s, want the conventional map", sourceMap.URL, sourceMap.Discovery)
+	}
+
+	var statuses []int
+	for _, attempt := range attempts {
+		statuses = append(statuses, attempt.StatusCode)
+	}
+	if want := []int{404, 200, 200}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("attempt statuses = %v, want %v", statuses, want)
+	}
+	if attempts[0].Err == nil || attempts[1].Err == nil || attempts[2].Err != nil {
+		t.Errorf("attempts = %+v, want the first two rejected", attempts)
+	}
+}
+
+func TestFetchSourceMapPrefersInline(t *testing.T) {
+	jsURL := "https://cdn.example.org/app.js"
+	inline := "data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(testMap))
+	fetch := fakeFetch(map[string]client.Response{
+		"https://cdn.example.org/app.js.map": {StatusCode: 200, Body: testMap},
+	})
+
+	sourceMap, attempts, err := FetchSourceMap(jsURL, "//# sourceMappingURL="+inline, nil, fetch, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sourceMap.Discovery != DiscoveryInline || len(attempts) != 0 {
+		t.Errorf("found via %s after %d downloads, want the inline map without downloads", sourceMap.Discovery, len(attempts))
+	}
+	// Sources of an inline map resolve against the script
+	if got := sourceMap.SourceURL(0); got != "https://cdn.example.org/src/app.js" {
+		t.Errorf("SourceURL(0) = %q, want it resolved against the script", got)
+	}
+}
+
+func TestDecodeDataURI(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(testMap))
+	tests := []struct {
+		name string
+		uri  string
+	}{
+		{"base64", "data:application/json;base64," + encoded},
+		{"base64 with charset", "data:application/json;charset=utf-8;base64," + encoded},
+		{"unpadded base64", "data:application/json;base64," + base64.RawStdEncoding.EncodeToString([]byte(testMap))},
+		{"percent-encoded", "data:application/json;charset=utf-8," + url.PathEscape(testMap)},
+		{"plain", "data:application/json," + testMap},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceMap, err := decodeDataURI(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sourceMap.Sources, []string{"src/app.js"}) {
+				t.Errorf("sources = %q, want src/app.js", sourceMap.Sources)
+			}
+		})
+	}
+
+	for _, uri := range []string{"data:application/json;base64", "data:application/json;base64,!!!!", "data:application/json,%zz"} {
+		if _, err := decodeDataURI(uri); err == nil {
+			t.Errorf("decodeDataURI(%q) succeeded, want an error", uri)
+		}
+	}
+}
+
+func TestParseStripsXSSIPrefix(t *testing.T) {
+	for _, prefix := range []string{")]}'\n", ")]}\n", ")]}'\r\n", "  )]}'\n"} {
+		sourceMap, err := Parse([]byte(prefix + testMap))
+		if err != nil {
+			t.Errorf("Parse with prefix %q: %v", prefix, err)
+			continue
+		}
+		if sourceMap.Version != 3 || len(sourceMap.Sources) != 1 {
+			t.Errorf("Parse with prefix %q = %+v", prefix, sourceMap)
+		}
+	}
+
+	if _, err := Parse([]byte(`{"version":3,"sources":[]}`)); err == nil {
+		t.Error("Parse accepted a map without sources")
+	}
+	if _, err := Parse([]byte("<html></html>")); err == nil {
+		t.Error("Parse accepted HTML")
+	}
+}
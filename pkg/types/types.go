@@ -1,155 +1,407 @@
 package types
 
-// Finding represents a single finding
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Finding categories
+const (
+	CategoryEndpoints = "endpoints"
+	CategoryURLs      = "urls"
+	CategorySecrets   = "secrets"
+	CategoryEmails    = "emails"
+	CategoryFiles     = "files"
+)
+
+// Categories lists every finding category in display order
+var Categories = []string{
+	CategoryEndpoints,
+	CategoryURLs,
+	CategorySecrets,
+	CategoryEmails,
+	CategoryFiles,
+}
+
+// Location is where a finding appeared in the original content. Line and
+// Column are 1-based, Column counts characters. A zero Line means the
+// position could not be determined.
+type Location struct {
+	Offset  int    `json:"offset"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Snippet string `json:"snippet,omitempty"`
+	// Original is where the finding came from according to the source map
+	// of a bundle, if any
+	Original *OriginalLocation `json:"original,omitempty"`
+}
+
+// OriginalLocation is a position in an original source file. Line and
+// Column are 1-based.
+type OriginalLocation struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Finding is a single finding of any category
 type Finding struct {
+	// ID identifies the finding by category and value, so the same value
+	// found in different sources shares an ID
+	ID       string `json:"id"`
 	Category string `json:"category"`
-	Value    string `json:"value"`
-	Source   string `json:"source"`
+	// Type is the human readable name of the rule, e.g. "AWS Key"
+	Type   string `json:"type"`
+	RuleID string `json:"rule_id"`
+	Value  string `json:"value"`
+	// Masked is the value with its middle hidden. It is only set for secrets,
+	// Value always holds the raw value.
+	Masked     string   `json:"masked,omitempty"`
+	Severity   string   `json:"severity"`
+	Confidence string   `json:"confidence"`
+	Source     string   `json:"source"`
+	Location   Location `json:"location"`
+	Tags       []string `json:"tags,omitempty"`
+	// Params names the unresolved parts of a dynamic endpoint, in the
+	// order their {name} placeholders appear in Value
+	Params []string `json:"params,omitempty"`
+	// Request describes how an endpoint is called, when a call site was found
+	Request *Request `json:"request,omitempty"`
+	// Occurrences counts how often the value appeared in the source; Location
+	// is that of the first occurrence
+	Occurrences int `json:"occurrences"`
+}
+
+// Request describes the HTTP requests made to an endpoint, merged across
+// every call site that was found
+type Request struct {
+	Methods []string `json:"methods,omitempty"`
+	Query   []string `json:"query,omitempty"`
+	Headers []string `json:"headers,omitempty"`
+	Body    []string `json:"body,omitempty"`
+}
+
+// Merge adds the methods, query parameters, headers and body keys of other
+// that are not yet in r
+func (r *Request) Merge(other *Request) {
+	if other == nil {
+		return
+	}
+	r.Methods = appendMissing(r.Methods, other.Methods...)
+	r.Query = appendMissing(r.Query, other.Query...)
+	r.Headers = appendMissing(r.Headers, other.Headers...)
+	r.Body = appendMissing(r.Body, other.Body...)
+}
+
+// appendMissing appends the values not already in list
+func appendMissing(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
 }
 
-// Findings holds all categorized findings
+// mergeRequest merges a request into the finding's request
+func (f *Finding) mergeRequest(request *Request) {
+	if request == nil {
+		return
+	}
+	if f.Request == nil {
+		f.Request = &Request{}
+	}
+	f.Request.Merge(request)
+}
+
+// MaskSecret hides the middle of long secrets and all but the first
+// characters of short ones
+func MaskSecret(value string) string {
+	switch {
+	case len(value) > 20:
+		return value[:10] + "..." + value[len(value)-4:]
+	case len(value) > 8:
+		return value[:4] + "..."
+	default:
+		return strings.Repeat("*", len(value))
+	}
+}
+
+// FindingID returns the stable ID for a value of a category
+func FindingID(category, value string) string {
+	sum := sha1.Sum([]byte(category + "\x00" + value))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Findings holds the findings of a single source in discovery order
 type Findings struct {
-	Endpoints map[string]bool
-	URLs      map[string]bool
-	Secrets   []Finding
-	Emails    map[string]bool
-	Files     map[string]bool
-	SeenKeys  map[string]bool
-	Verbose   bool
+	Items []Finding
+	// SeenKeys maps the category and value of each finding to its index in Items
+	SeenKeys map[string]int
+	Verbose  bool
+
+	// positions holds the category, value and offset of every recorded match
+	positions map[string]bool
 }
 
-// SourceFinding tracks findings from a specific source
+// SourceFinding tracks findings from a specific source. In
+// AggregatedFindings.Sources Count is the number of distinct findings in the
+// source, in AggregatedFinding.Sources it is the number of occurrences of the
+// finding in the source.
 type SourceFinding struct {
-	Source     string
-	URL        string
-	StatusCode int
-	Count      int
+	Source     string   `json:"source"`
+	URL        string   `json:"url"`
+	StatusCode int      `json:"status_code"`
+	Count      int      `json:"count"`
+	Location   Location `json:"location"`
 }
 
-// SecretFinding includes source information
-type SecretFinding struct {
-	Value      string
-	Type       string
-	Source     string
-	URL        string
-	StatusCode int
+// AggregatedFinding is a finding deduplicated across sources
+type AggregatedFinding struct {
+	Finding
+	Sources []SourceFinding `json:"sources"`
 }
 
-// AggregatedFindings holds findings across multiple sources
+// AggregatedFindings holds findings across multiple sources.
+// AddFindings is safe for concurrent use.
 type AggregatedFindings struct {
-	Endpoints map[string][]SourceFinding
-	URLs      map[string][]SourceFinding
-	Secrets   []SecretFinding
-	Emails    map[string][]SourceFinding
-	Files     map[string][]SourceFinding
-	Sources   map[string]SourceFinding
-	SeenKeys  map[string]bool
-	Verbose   bool
+	mu sync.Mutex
+
+	// Findings holds every finding in discovery order
+	Findings []*AggregatedFinding
+	// Sources maps the URL of each source, or its name if it has no URL, to
+	// the source
+	Sources map[string]SourceFinding
+	Verbose bool
+
+	// OnAdd, if set, is called at the end of every AddFindings with the
+	// source and the aggregated findings it contributed to, so results can
+	// be streamed as they are found. It runs under the lock of af and must
+	// not call back into it.
+	OnAdd func(source SourceFinding, findings []*AggregatedFinding)
+
+	byID map[string]*AggregatedFinding
 }
 
 // NewFindings creates a new Findings instance
 func NewFindings(verbose bool) *Findings {
 	return &Findings{
-		Endpoints: make(map[string]bool),
-		URLs:      make(map[string]bool),
-		Secrets:   []Finding{},
-		Emails:    make(map[string]bool),
-		Files:     make(map[string]bool),
-		SeenKeys:  make(map[string]bool),
+		Items:     []Finding{},
+		SeenKeys:  make(map[string]int),
 		Verbose:   verbose,
+		positions: make(map[string]bool),
 	}
 }
 
+// Add records a finding and reports whether it was new. A finding with an
+// already recorded category and value merges its Request and increments
+// Occurrences only if it is at a new position, so several rules matching
+// the same text count it once.
+func (f *Findings) Add(finding Finding) bool {
+	key := finding.Category + ":" + finding.Value
+	newPosition := f.recordPosition(key, finding.Location)
+	if i, exists := f.SeenKeys[key]; exists {
+		if newPosition {
+			f.Items[i].Occurrences++
+		}
+		f.Items[i].mergeRequest(finding.Request)
+		return false
+	}
+	f.SeenKeys[key] = len(f.Items)
+
+	if finding.ID == "" {
+		finding.ID = FindingID(finding.Category, finding.Value)
+	}
+	finding.Occurrences = 1
+	f.Items = append(f.Items, finding)
+	return true
+}
+
+// recordPosition records the position of a match of the finding with the
+// given key and reports whether it is new. Matches at an unknown position
+// (Line 0) are always new.
+func (f *Findings) recordPosition(key string, loc Location) bool {
+	if loc.Line == 0 {
+		return true
+	}
+	if f.positions == nil {
+		f.positions = make(map[string]bool)
+	}
+	position := key + "@" + strconv.Itoa(loc.Offset)
+	if f.positions[position] {
+		return false
+	}
+	f.positions[position] = true
+	return true
+}
+
+// Merge records a finding like Add, but a finding with an already recorded
+// category and value only gains its Request and Tags, without counting
+// another occurrence. It is used for details learned about a value that was
+// already counted, such as the call site of an endpoint literal.
+func (f *Findings) Merge(finding Finding) bool {
+	key := finding.Category + ":" + finding.Value
+	i, exists := f.SeenKeys[key]
+	if !exists {
+		return f.Add(finding)
+	}
+
+	item := &f.Items[i]
+	item.mergeRequest(finding.Request)
+	// Copy the tags, which may be shared with the rule that found the value
+	item.Tags = appendMissing(append([]string(nil), item.Tags...), finding.Tags...)
+	if len(item.Params) == 0 {
+		item.Params = finding.Params
+	}
+	return false
+}
+
+// ByCategory returns the findings of a category in discovery order
+func (f *Findings) ByCategory(category string) []Finding {
+	var result []Finding
+	for _, finding := range f.Items {
+		if finding.Category == category {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
 // NewAggregatedFindings creates a new aggregated findings instance
 func NewAggregatedFindings() *AggregatedFindings {
 	return &AggregatedFindings{
-		Endpoints: make(map[string][]SourceFinding),
-		URLs:      make(map[string][]SourceFinding),
-		Secrets:   []SecretFinding{},
-		Emails:    make(map[string][]SourceFinding),
-		Files:     make(map[string][]SourceFinding),
-		Sources:   make(map[string]SourceFinding),
-		SeenKeys:  make(map[string]bool),
-		Verbose:   false,
+		Findings: []*AggregatedFinding{},
+		Sources:  make(map[string]SourceFinding),
+		Verbose:  false,
+		byID:     make(map[string]*AggregatedFinding),
 	}
 }
 
-// AddFindings adds findings from a source
+// AddFindings adds findings from a source. A finding already recorded from
+// another source gains that source in its Sources.
 func (af *AggregatedFindings) AddFindings(findings *Findings, source, url string, statusCode int) {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	// Track source
-	if _, exists := af.Sources[source]; !exists {
-		af.Sources[source] = SourceFinding{
+	key := sourceKey(source, url)
+	sf, exists := af.Sources[key]
+	if !exists {
+		sf = SourceFinding{
 			Source:     source,
 			URL:        url,
 			StatusCode: statusCode,
-			Count:      0,
 		}
 	}
+	sf.Count += len(findings.Items)
+	af.Sources[key] = sf
 
-	// Add endpoints
-	for endpoint := range findings.Endpoints {
-		key := "ep:" + endpoint
-		if !af.SeenKeys[key] {
-			af.SeenKeys[key] = true
-			af.Endpoints[endpoint] = append(af.Endpoints[endpoint], SourceFinding{
-				Source:     source,
-				URL:        url,
-				StatusCode: statusCode,
-			})
+	var added []*AggregatedFinding
+	for _, finding := range findings.Items {
+		occurrences := finding.Occurrences
+		if occurrences == 0 {
+			occurrences = 1
 		}
-	}
 
-	// Add URLs
-	for u := range findings.URLs {
-		key := "url:" + u
-		if !af.SeenKeys[key] {
-			af.SeenKeys[key] = true
-			af.URLs[u] = append(af.URLs[u], SourceFinding{
-				Source:     source,
-				URL:        url,
-				StatusCode: statusCode,
-			})
+		aggregated, exists := af.byID[finding.ID]
+		if !exists {
+			finding.Source = source
+			aggregated = &AggregatedFinding{Finding: finding}
+			// Copy the request so later merges leave the source's findings untouched
+			aggregated.Request = nil
+			aggregated.mergeRequest(finding.Request)
+			af.byID[finding.ID] = aggregated
+			af.Findings = append(af.Findings, aggregated)
+		} else {
+			aggregated.Occurrences += occurrences
+			aggregated.mergeRequest(finding.Request)
 		}
+
+		aggregated.addSource(SourceFinding{
+			Source:     source,
+			URL:        url,
+			StatusCode: statusCode,
+			Count:      occurrences,
+			Location:   finding.Location,
+		})
+		added = append(added, aggregated)
 	}
 
-	// Add secrets
-	for _, secret := range findings.Secrets {
-		key := "sec:" + secret.Value
-		if !af.SeenKeys[key] {
-			af.SeenKeys[key] = true
-			af.Secrets = append(af.Secrets, SecretFinding{
-				Value:      secret.Value,
-				Type:       secret.Category,
-				Source:     source,
-				URL:        url,
-				StatusCode: statusCode,
-			})
+	if af.OnAdd != nil {
+		af.OnAdd(SourceFinding{Source: source, URL: url, StatusCode: statusCode, Count: len(findings.Items)}, added)
+	}
+}
+
+// sourceKey identifies a source by its URL, so different files sharing a
+// name such as main.js stay apart, or by its name if it has no URL
+func sourceKey(source, url string) string {
+	if url != "" {
+		return url
+	}
+	return source
+}
+
+// addSource records a source of the finding, merging it into an existing
+// entry for the same source URL
+func (f *AggregatedFinding) addSource(source SourceFinding) {
+	key := sourceKey(source.Source, source.URL)
+	for i := range f.Sources {
+		if sourceKey(f.Sources[i].Source, f.Sources[i].URL) == key {
+			f.Sources[i].Count += source.Count
+			return
 		}
 	}
+	f.Sources = append(f.Sources, source)
+}
 
-	// Add emails
-	for email := range findings.Emails {
-		key := "email:" + email
-		if !af.SeenKeys[key] {
-			af.SeenKeys[key] = true
-			af.Emails[email] = append(af.Emails[email], SourceFinding{
-				Source:     source,
-				URL:        url,
-				StatusCode: statusCode,
-			})
+// ByCategory returns the findings of a category. Secrets keep discovery
+// order, every other category is sorted by value.
+func (af *AggregatedFindings) ByCategory(category string) []*AggregatedFinding {
+	var result []*AggregatedFinding
+	for _, finding := range af.Findings {
+		if finding.Category == category {
+			result = append(result, finding)
 		}
 	}
+	if category != CategorySecrets {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Value < result[j].Value
+		})
+	}
+	return result
+}
 
-	// Add files
-	for file := range findings.Files {
-		key := "file:" + file
-		if !af.SeenKeys[key] {
-			af.SeenKeys[key] = true
-			af.Files[file] = append(af.Files[file], SourceFinding{
-				Source:     source,
-				URL:        url,
-				StatusCode: statusCode,
-			})
+// Count returns the number of findings in a category
+func (af *AggregatedFindings) Count(category string) int {
+	count := 0
+	for _, finding := range af.Findings {
+		if finding.Category == category {
+			count++
 		}
 	}
+	return count
+}
+
+// SortedSources returns the tracked sources sorted by name
+func (af *AggregatedFindings) SortedSources() []SourceFinding {
+	sources := make([]SourceFinding, 0, len(af.Sources))
+	for _, source := range af.Sources {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Source < sources[j].Source
+	})
+	return sources
 }
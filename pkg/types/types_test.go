@@ -0,0 +1,40 @@
+package types
+
+import "testing"
+
+func TestAddFindingsKeepsSameNamedSourcesApart(t *testing.T) {
+	af := NewAggregatedFindings()
+	for _, url := range []string{"https://a.example.org/main.js", "https://b.example.org/main.js"} {
+		findings := NewFindings(false)
+		findings.Add(Finding{Category: CategoryEndpoints, Value: "/api/v1/cart"})
+		af.AddFindings(findings, "main.js", url, 200)
+	}
+
+	if len(af.Sources) != 2 {
+		t.Errorf("got %d sources, want 2", len(af.Sources))
+	}
+	if len(af.Findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(af.Findings))
+	}
+	if sources := af.Findings[0].Sources; len(sources) != 2 {
+		t.Errorf("finding has %d sources, want 2: %+v", len(sources), sources)
+	}
+}
+
+func TestAddCountsDistinctPositions(t *testing.T) {
+	findings := NewFindings(false)
+	at := func(offset int) Finding {
+		return Finding{Category: CategoryEmails, Value: "ops@corp-internal.io", Location: Location{Offset: offset, Line: 1, Column: offset + 1}}
+	}
+
+	findings.Add(at(9))
+	findings.Add(at(9)) // a second rule matching the same text
+	if got := findings.Items[0].Occurrences; got != 1 {
+		t.Errorf("two rules on one match counted %d occurrences, want 1", got)
+	}
+
+	findings.Add(at(40))
+	if got := findings.Items[0].Occurrences; got != 2 {
+		t.Errorf("second position counted %d occurrences, want 2", got)
+	}
+}